@@ -0,0 +1,96 @@
+package main
+
+import (
+    "encoding/xml"
+)
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+type AtomFeed struct {
+    XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+    Title    AtomText    `xml:"title"`
+    Subtitle AtomText    `xml:"subtitle"`
+    Link     []AtomLink  `xml:"link"`
+    Entry    []AtomEntry `xml:"entry"`
+}
+
+type AtomEntry struct {
+    ID        string     `xml:"id"`
+    Title     AtomText   `xml:"title"`
+    Link      []AtomLink `xml:"link"`
+    Updated   string     `xml:"updated"`
+    Published string     `xml:"published"`
+    Summary   AtomText   `xml:"summary"`
+    Content   AtomText   `xml:"content"`
+}
+
+type AtomLink struct {
+    Href   string `xml:"href,attr"`
+    Rel    string `xml:"rel,attr"`
+    Type   string `xml:"type,attr"`
+    Length string `xml:"length,attr"`
+}
+
+// AtomText holds an Atom text construct. Plain and escaped html content
+// arrive as character data, while type="xhtml" content is inline markup.
+type AtomText struct {
+    Type  string `xml:"type,attr"`
+    Text  string `xml:",chardata"`
+    Inner string `xml:",innerxml"`
+}
+
+func (t AtomText) String() string {
+    if t.Type == "xhtml" {
+        return t.Inner
+    }
+    return t.Text
+}
+
+// alternateLink returns the href of the rel="alternate" link, which is
+// also the default when rel is omitted.
+func alternateLink(links []AtomLink) string {
+    for _, link := range links {
+        if link.Rel == "" || link.Rel == "alternate" {
+            return link.Href
+        }
+    }
+    if len(links) > 0 {
+        return links[0].Href
+    }
+    return ""
+}
+
+// toRSS maps the Atom feed onto the RSS item model stored by scrapeFeed.
+func (f *AtomFeed) toRSS() *RSSFeed {
+    var feed RSSFeed
+    feed.Channel.Title = f.Title.String()
+    feed.Channel.Link = alternateLink(f.Link)
+    feed.Channel.AtomLinks = f.Link
+    feed.Channel.Description = f.Subtitle.String()
+
+    for _, entry := range f.Entry {
+        item := RSSItem{
+            Title:       entry.Title.String(),
+            Link:        alternateLink(entry.Link),
+            Description: entry.Summary.String(),
+            PubDate:     entry.Published,
+            Content:     entry.Content.String(),
+            GUID:        entry.ID,
+        }
+        if item.PubDate == "" {
+            item.PubDate = entry.Updated
+        }
+        for _, link := range entry.Link {
+            if link.Rel == "enclosure" {
+                item.Enclosures = append(item.Enclosures, RSSEnclosure{
+                    URL:    link.Href,
+                    Length: link.Length,
+                    Type:   link.Type,
+                })
+            }
+        }
+        feed.Channel.Item = append(feed.Channel.Item, item)
+    }
+
+    return &feed
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+    "bytes"
+    "fmt"
+    "mime"
+    "regexp"
+    "strings"
+
+    "golang.org/x/text/encoding"
+    "golang.org/x/text/encoding/htmlindex"
+    "golang.org/x/text/encoding/ianaindex"
+)
+
+// charsetAliases are labels seen in feeds that neither browsers nor the IANA
+// registry know.
+var charsetAliases = map[string]string{
+    "latin9": "iso-8859-15",
+}
+
+var xmlEncodingAttr = regexp.MustCompile(`^(\s*<\?xml[^>]*?encoding\s*=\s*)["'][^"']*["']`)
+
+// toUTF8 transcodes a feed body to UTF-8. The charset comes from the
+// Content-Type header if it has one and the XML declaration otherwise.
+// The declaration is rewritten to match, since encoding/xml refuses to
+// parse documents that declare anything but UTF-8.
+func toUTF8(body []byte, contentType string) ([]byte, error) {
+    label := ""
+    if _, params, err := mime.ParseMediaType(contentType); err == nil {
+        label = params["charset"]
+    }
+    if label == "" {
+        label = declaredEncoding(body)
+    }
+    label = strings.ToLower(strings.TrimSpace(label))
+
+    switch label {
+    case "", "utf-8", "utf8":
+    default:
+        enc, err := charsetEncoding(label)
+        if err != nil {
+            return nil, err
+        }
+        body, err = enc.NewDecoder().Bytes(body)
+        if err != nil {
+            return nil, fmt.Errorf("couldn't decode %s: %w", label, err)
+        }
+    }
+
+    return xmlEncodingAttr.ReplaceAll(body, []byte(`${1}"UTF-8"`)), nil
+}
+
+func declaredEncoding(body []byte) string {
+    match := xmlEncodingAttr.Find(body)
+    if match == nil {
+        return ""
+    }
+    quote := match[len(match)-1]
+    start := bytes.LastIndexByte(match[:len(match)-1], quote)
+    return string(match[start+1 : len(match)-1])
+}
+
+// charsetEncoding looks the label up the way browsers do, so ISO-8859-1 and
+// US-ASCII are read as windows-1252, which is what servers labelling their
+// feeds that way usually send. Labels browsers don't know fall back to the
+// IANA registry.
+func charsetEncoding(label string) (encoding.Encoding, error) {
+    if alias, ok := charsetAliases[label]; ok {
+        label = alias
+    }
+    if enc, err := htmlindex.Get(label); err == nil {
+        return enc, nil
+    }
+    if enc, err := ianaindex.IANA.Encoding(label); err == nil && enc != nil {
+        return enc, nil
+    }
+    return nil, fmt.Errorf("unsupported charset %q", label)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestToUTF8(t *testing.T) {
+    tests := []struct {
+        name        string
+        body        string
+        contentType string
+        want        string
+    }{
+        {"no charset", "caf\xc3\xa9", "application/rss+xml", "café"},
+        {"UTF-8 header", "caf\xc3\xa9", "application/rss+xml; charset=utf-8", "café"},
+        {"ISO-8859-1 header", "caf\xe9", "text/xml; charset=ISO-8859-1", "café"},
+        {"ISO-8859-1 read as windows-1252", "\x80 \x93quoted\x94", "text/xml; charset=iso-8859-1", "€ “quoted”"},
+        {"windows-1252 header", "\x80 \x96 \x85", "text/xml; charset=windows-1252", "€ – …"},
+        {"ISO-8859-15 header", "\xa4 \xbd", "text/xml; charset=ISO-8859-15", "€ œ"},
+        {"latin9 alias", "\xa4", "text/xml; charset=latin9", "€"},
+        {"ISO-8859-2 header", "\xb1\xe6", "text/xml; charset=iso-8859-2", "ąć"},
+        {"windows-1250 header", "\x9a\x9e", "text/xml; charset=windows-1250", "šž"},
+        {"KOI8-R header", "\xd2\xd5\xd3\xd3\xcb\xc9\xca", "text/xml; charset=koi8-r", "русский"},
+        {"header wins over declaration", `<?xml version="1.0" encoding="UTF-8"?>` + "caf\xe9", "text/xml; charset=iso-8859-1", `<?xml version="1.0" encoding="UTF-8"?>café`},
+        {"declaration", `<?xml version="1.0" encoding="ISO-8859-1"?>` + "caf\xe9", "", `<?xml version="1.0" encoding="UTF-8"?>café`},
+        {"single quoted declaration", `<?xml version='1.0' encoding='windows-1252'?>` + "\x80", "application/xml", `<?xml version='1.0' encoding="UTF-8"?>€`},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := toUTF8([]byte(tt.body), tt.contentType)
+            if err != nil {
+                t.Fatalf("toUTF8: %v", err)
+            }
+            if string(got) != tt.want {
+                t.Errorf("toUTF8 = %q, want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestToUTF8UnsupportedCharset(t *testing.T) {
+    _, err := toUTF8([]byte("data"), "text/xml; charset=x-made-up")
+    if err == nil || !strings.Contains(err.Error(), "unsupported charset") {
+        t.Errorf("toUTF8 error = %v, want an unsupported charset error", err)
+    }
+}
+
+func TestDecodeFeedLatin1(t *testing.T) {
+    body := `<?xml version="1.0" encoding="ISO-8859-1"?>` +
+        "<rss><channel><title>Caf\xe9</title><item><title>\x93Br\xfbl\xe9e\x94</title></item></channel></rss>"
+
+    feed, err := decodeFeed([]byte(body), "application/rss+xml")
+    if err != nil {
+        t.Fatalf("decodeFeed: %v", err)
+    }
+    if feed.Channel.Title != "Café" {
+        t.Errorf("channel title = %q, want %q", feed.Channel.Title, "Café")
+    }
+    if len(feed.Channel.Item) != 1 || feed.Channel.Item[0].Title != "“Brûlée”" {
+        t.Errorf("items = %+v, want one titled %q", feed.Channel.Item, "“Brûlée”")
+    }
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+    "fmt"
+    "strings"
+    "time"
+    "unicode"
+)
+
+// pubDateLayouts are tried in order once parsePubDate has normalised the
+// value: day names are gone, month names are English and any zone
+// abbreviation has been turned into a numeric offset.
+var pubDateLayouts = []string{
+    time.RFC3339Nano,
+    time.RFC3339,
+    "2006-01-02T15:04:05-0700",
+    "2006-01-02T15:04-07:00",
+    "2006-01-02T15:04Z07:00",
+    "2006-01-02T15:04:05",
+    "2006-01-02 15:04:05 -0700",
+    "2006-01-02 15:04:05-07:00",
+    "2006-01-02 15:04:05",
+    "2006-01-02 15:04",
+    "2006-01-02",
+    "2 Jan 2006 15:04:05 -0700",
+    "2 Jan 2006 15:04:05 -07:00",
+    "2 Jan 2006 15:04 -0700",
+    "2 Jan 2006 15:04:05",
+    "2 Jan 2006 15:04",
+    "2 Jan 2006",
+    "2 Jan 06 15:04:05 -0700",
+    "2 Jan 06 15:04 -0700",
+    "2 Jan 06 15:04:05",
+    "2 Jan 06",
+    "2 January 2006 15:04:05 -0700",
+    "2 January 2006 15:04 -0700",
+    "2 January 2006 15:04:05",
+    "2 January 2006",
+    "Jan 2 2006 15:04:05 -0700",
+    "Jan 2 2006 15:04:05",
+    "Jan 2 2006",
+    "January 2 2006 15:04:05 -0700",
+    "January 2 2006",
+    "Jan 2 15:04:05 2006",
+    "Jan 2 15:04:05 -0700 2006",
+    "2006/01/02 15:04:05 -0700",
+    "2006/01/02 15:04:05",
+    "2006/01/02",
+    // Unknown zone abbreviations are read as UTC rather than dropping the date.
+    "2 Jan 2006 15:04:05 MST",
+    "2 Jan 2006 15:04 MST",
+    "2006-01-02 15:04:05 MST",
+    "Jan 2 15:04:05 MST 2006",
+}
+
+// zoneOffsets covers the abbreviations seen in real feeds. Go's parser
+// accepts any abbreviation but silently treats unknown ones as UTC.
+var zoneOffsets = map[string]string{
+    "UT":   "+0000",
+    "UTC":  "+0000",
+    "GMT":  "+0000",
+    "Z":    "+0000",
+    "WET":  "+0000",
+    "WEST": "+0100",
+    "BST":  "+0100",
+    "IST":  "+0530",
+    "CET":  "+0100",
+    "CEST": "+0200",
+    "MET":  "+0100",
+    "MEST": "+0200",
+    "MEZ":  "+0100",
+    "MESZ": "+0200",
+    "EET":  "+0200",
+    "EEST": "+0300",
+    "MSK":  "+0300",
+    "EST":  "-0500",
+    "EDT":  "-0400",
+    "CST":  "-0600",
+    "CDT":  "-0500",
+    "MST":  "-0700",
+    "MDT":  "-0600",
+    "PST":  "-0800",
+    "PDT":  "-0700",
+    "AKST": "-0900",
+    "AKDT": "-0800",
+    "HST":  "-1000",
+    "AST":  "-0400",
+    "ADT":  "-0300",
+    "NST":  "-0330",
+    "NDT":  "-0230",
+    "JST":  "+0900",
+    "KST":  "+0900",
+    "HKT":  "+0800",
+    "SGT":  "+0800",
+    "AWST": "+0800",
+    "ACST": "+0930",
+    "ACDT": "+1030",
+    "AEST": "+1000",
+    "AEDT": "+1100",
+    "NZST": "+1200",
+    "NZDT": "+1300",
+}
+
+// monthNames maps non-English month names and abbreviations onto the
+// English abbreviations Go's layouts expect.
+var monthNames = map[string]string{
+    // French
+    "janvier": "Jan", "janv": "Jan", "février": "Feb", "fevrier": "Feb", "févr": "Feb", "fevr": "Feb",
+    "sept": "Sep", "mars": "Mar", "avril": "Apr", "avr": "Apr", "mai": "May", "juin": "Jun", "juillet": "Jul",
+    "juil": "Jul", "août": "Aug", "aout": "Aug", "septembre": "Sep", "octobre": "Oct",
+    "novembre": "Nov", "décembre": "Dec", "decembre": "Dec", "déc": "Dec",
+    // German
+    "januar": "Jan", "jän": "Jan", "jänner": "Jan", "februar": "Feb", "märz": "Mar", "maerz": "Mar",
+    "mär": "Mar", "juni": "Jun", "juli": "Jul", "okt": "Oct", "oktober": "Oct",
+    "dezember": "Dec", "dez": "Dec",
+    // Spanish
+    "enero": "Jan", "ene": "Jan", "febrero": "Feb", "marzo": "Mar", "abril": "Apr", "abr": "Apr",
+    "mayo": "May", "junio": "Jun", "julio": "Jul", "agosto": "Aug", "ago": "Aug",
+    "septiembre": "Sep", "setiembre": "Sep", "octubre": "Oct", "noviembre": "Nov",
+    "diciembre": "Dec", "dic": "Dec",
+    // Italian
+    "gennaio": "Jan", "gen": "Jan", "febbraio": "Feb", "aprile": "Apr",
+    "maggio": "May", "mag": "May", "giugno": "Jun", "giu": "Jun", "luglio": "Jul", "lug": "Jul",
+    "settembre": "Sep", "set": "Sep", "ottobre": "Oct", "ott": "Oct", "dicembre": "Dec",
+    // Dutch
+    "januari": "Jan", "februari": "Feb", "maart": "Mar", "mrt": "Mar", "mei": "May",
+    "augustus": "Aug",
+    // Portuguese
+    "janeiro": "Jan", "fevereiro": "Feb", "fev": "Feb", "março": "Mar", "marco": "Mar",
+    "maio": "May", "junho": "Jun", "julho": "Jul", "setembro": "Sep", "outubro": "Oct",
+    "out": "Oct", "novembro": "Nov", "dezembro": "Dec",
+}
+
+// parsePubDate parses the publication dates found in RSS, Atom, RDF and
+// JSON feeds, which in practice stray far from the formats the specs ask for.
+func parsePubDate(value string) (time.Time, error) {
+    normalized := normalizePubDate(value)
+    if normalized == "" {
+        return time.Time{}, fmt.Errorf("empty date")
+    }
+
+    for _, layout := range pubDateLayouts {
+        if t, err := time.Parse(layout, normalized); err == nil {
+            return t.UTC(), nil
+        }
+    }
+    return time.Time{}, fmt.Errorf("unrecognised date %q", value)
+}
+
+func normalizePubDate(value string) string {
+    // Drop a trailing comment as in "+0000 (UTC)", which mail-style dates
+    // often carry after the zone.
+    value = strings.TrimSpace(value)
+    if strings.HasSuffix(value, ")") {
+        if i := strings.LastIndex(value, "("); i > 0 {
+            value = value[:i]
+        }
+    }
+
+    fields := strings.FieldsFunc(value, func(r rune) bool {
+        return unicode.IsSpace(r) || r == ','
+    })
+
+    var out []string
+    for i, field := range fields {
+        // Drop the day of the week in whatever language it is in; it adds
+        // nothing once we have the date. It is only told apart from a
+        // leading month by a month name later on, as the likes of "mar"
+        // are a day in one language and a month in another.
+        if i == 0 && isLetters(field) && hasMonthName(fields[1:]) {
+            continue
+        }
+
+        // Trailing dots come from abbreviations ("janv.") and German style
+        // day numbers ("14. März").
+        field = strings.TrimSuffix(field, ".")
+
+        if month, ok := monthNames[strings.ToLower(field)]; ok {
+            field = month
+        }
+
+        // Only the last field can be a zone abbreviation, or the one before
+        // a trailing year as in Unix dates.
+        last := len(fields) - 1
+        if i == last || (i == last-1 && isYear(fields[last])) {
+            if offset, ok := zoneOffsets[strings.ToUpper(field)]; ok {
+                field = offset
+            }
+        }
+
+        out = append(out, field)
+    }
+
+    return strings.Join(out, " ")
+}
+
+func hasMonthName(fields []string) bool {
+    for _, field := range fields {
+        if _, ok := monthNames[strings.ToLower(strings.TrimSuffix(field, "."))]; ok || isEnglishMonth(field) {
+            return true
+        }
+    }
+    return false
+}
+
+func isYear(s string) bool {
+    if len(s) != 4 {
+        return false
+    }
+    for _, r := range s {
+        if r < '0' || r > '9' {
+            return false
+        }
+    }
+    return true
+}
+
+func isLetters(s string) bool {
+    for _, r := range s {
+        if !unicode.IsLetter(r) && r != '.' {
+            return false
+        }
+    }
+    return s != ""
+}
+
+func isEnglishMonth(s string) bool {
+    s = strings.TrimSuffix(s, ".")
+    if len(s) < 3 {
+        return false
+    }
+    for m := time.January; m <= time.December; m++ {
+        if strings.EqualFold(s, m.String()) || strings.EqualFold(s, m.String()[:3]) {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+    "testing"
+    "time"
+)
+
+func TestParsePubDate(t *testing.T) {
+    tests := []struct {
+        name  string
+        value string
+        want  time.Time
+    }{
+        {"RFC1123Z", "Mon, 02 Jan 2006 15:04:05 -0700", time.Date(2006, 1, 2, 22, 4, 5, 0, time.UTC)},
+        {"RFC1123 with GMT", "Mon, 02 Jan 2006 15:04:05 GMT", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+        {"RFC1123 with US zone", "Tue, 14 Jan 2020 10:00:00 EST", time.Date(2020, 1, 14, 15, 0, 0, 0, time.UTC)},
+        {"RFC1123 with European zone", "Tue, 14 Jan 2020 10:00:00 CEST", time.Date(2020, 1, 14, 8, 0, 0, 0, time.UTC)},
+        {"unknown zone read as UTC", "Tue, 14 Jan 2020 10:00:00 XYZ", time.Date(2020, 1, 14, 10, 0, 0, 0, time.UTC)},
+        {"trailing comment", "Mon, 02 Jan 2006 15:04:05 +0000 (UTC)", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+        {"single digit day", "Mon, 2 Jan 2006 15:04:05 +0000", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+        {"no seconds", "Mon, 02 Jan 2006 15:04 +0000", time.Date(2006, 1, 2, 15, 4, 0, 0, time.UTC)},
+        {"two digit year", "Mon, 02 Jan 06 15:04:05 +0000", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+        {"full month name", "2 January 2006 15:04:05 +0000", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+        {"RFC3339", "2006-01-02T15:04:05Z", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+        {"RFC3339 with offset", "2006-01-02T15:04:05+02:00", time.Date(2006, 1, 2, 13, 4, 5, 0, time.UTC)},
+        {"RFC3339 with fraction", "2006-01-02T15:04:05.123Z", time.Date(2006, 1, 2, 15, 4, 5, 123000000, time.UTC)},
+        {"ISO 8601 without colon in offset", "2006-01-02T15:04:05+0200", time.Date(2006, 1, 2, 13, 4, 5, 0, time.UTC)},
+        {"ISO 8601 without seconds", "2006-01-02T15:04+02:00", time.Date(2006, 1, 2, 13, 4, 0, 0, time.UTC)},
+        {"date only", "2006-01-02", time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC)},
+        {"SQL style", "2006-01-02 15:04:05", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+        {"slashes", "2006/01/02 15:04:05", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+        {"Unix date", "Mon Jan  2 15:04:05 MST 2006", time.Date(2006, 1, 2, 22, 4, 5, 0, time.UTC)},
+        {"leading month", "Mar 14 2020", time.Date(2020, 3, 14, 0, 0, 0, 0, time.UTC)},
+        {"French", "mar., 14 janv. 2020 10:00:00 +0100", time.Date(2020, 1, 14, 9, 0, 0, 0, time.UTC)},
+        {"Spanish", "mar, 14 ene 2020 10:00:00 +0100", time.Date(2020, 1, 14, 9, 0, 0, 0, time.UTC)},
+        {"German", "Dienstag, 14. März 2020 10:00", time.Date(2020, 3, 14, 10, 0, 0, 0, time.UTC)},
+        {"Italian", "mer, 15 gen 2020 10:00:00 +0100", time.Date(2020, 1, 15, 9, 0, 0, 0, time.UTC)},
+        {"Portuguese", "qua, 15 out 2020 10:00:00 +0000", time.Date(2020, 10, 15, 10, 0, 0, 0, time.UTC)},
+        {"Dutch", "di 14 mei 2020 10:00:00 +0200", time.Date(2020, 5, 14, 8, 0, 0, 0, time.UTC)},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := parsePubDate(tt.value)
+            if err != nil {
+                t.Fatalf("parsePubDate(%q): %v", tt.value, err)
+            }
+            if !got.Equal(tt.want) {
+                t.Errorf("parsePubDate(%q) = %s, want %s", tt.value, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestParsePubDateInvalid(t *testing.T) {
+    for _, value := range []string{"", "   ", "yesterday", "32 Jan 2006"} {
+        if got, err := parsePubDate(value); err == nil {
+            t.Errorf("parsePubDate(%q) = %s, want an error", value, got)
+        }
+    }
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "html"
+    "io"
+    "mime"
+    "net/http"
+    "net/url"
+    "regexp"
+    "strings"
+)
+
+// feedLinkTypes are the link types advertised for RSS, Atom and JSON Feed.
+var feedLinkTypes = map[string]bool{
+    "application/rss+xml":   true,
+    "application/atom+xml":  true,
+    "application/feed+json": true,
+    "application/rdf+xml":   true,
+}
+
+// wellKnownFeedPaths are tried when a page doesn't advertise its feed.
+var wellKnownFeedPaths = []string{
+    "/feed",
+    "/rss.xml",
+    "/atom.xml",
+    "/feed.xml",
+    "/index.xml",
+    "/feed.json",
+}
+
+var (
+    htmlLinkTag   = regexp.MustCompile(`(?is)<link\b[^>]*>`)
+    htmlAttribute = regexp.MustCompile(`(?s)([a-zA-Z-]+)\s*=\s*("[^"]*"|'[^']*'|[^\s"'>]+)`)
+)
+
+var errNoFeedFound = errors.New("no feed found")
+
+// discoverFeedURL returns the first feed an HTML page links to, or failing
+// that the first well-known feed path on the same site that serves a feed.
+// Anything that isn't an HTML page, including a URL that can't be fetched
+// right now, is returned as given and left for agg to make sense of.
+func discoverFeedURL(ctx context.Context, c *httpClient, pageURL string) (string, error) {
+    candidates, err := discoverFeeds(ctx, c, pageURL)
+    var fetchErr *pageFetchError
+    if errors.As(err, &fetchErr) {
+        fmt.Printf("Couldn't fetch %s (%v), using it as given\n", pageURL, fetchErr.Err)
+        return pageURL, nil
+    }
+    if err != nil {
+        return "", err
+    }
+    if len(candidates) > 1 {
+        fmt.Printf("Found %d feeds at %s:\n", len(candidates), pageURL)
+        for _, candidate := range candidates {
+            fmt.Printf("* %s\n", candidate)
+        }
+        fmt.Printf("Using %s\n", candidates[0])
+    }
+    return candidates[0], nil
+}
+
+func discoverFeeds(ctx context.Context, c *httpClient, pageURL string) ([]string, error) {
+    body, contentType, err := fetchPage(ctx, c, pageURL)
+    if err != nil {
+        return nil, &pageFetchError{Err: err}
+    }
+    if !isHTML(body, contentType) {
+        return []string{pageURL}, nil
+    }
+
+    base, err := url.Parse(pageURL)
+    if err != nil {
+        return nil, err
+    }
+
+    candidates := feedLinks(body, base)
+    if len(candidates) > 0 {
+        return candidates, nil
+    }
+
+    for _, path := range wellKnownFeedPaths {
+        candidate := base.ResolveReference(&url.URL{Path: path}).String()
+        body, contentType, err := fetchPage(ctx, c, candidate)
+        if err != nil {
+            continue
+        }
+        if isFeed(body, contentType) {
+            return []string{candidate}, nil
+        }
+    }
+
+    return nil, errNoFeedFound
+}
+
+func fetchPage(ctx context.Context, c *httpClient, pageURL string) ([]byte, string, error) {
+    req, err := c.newRequest(ctx, "GET", pageURL, nil)
+    if err != nil {
+        return nil, "", err
+    }
+
+    resp, err := c.Do(req)
+    if err != nil {
+        return nil, "", err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return nil, "", fmt.Errorf("unexpected status %s", resp.Status)
+    }
+
+    body, err := io.ReadAll(resp.Body)
+    if err != nil {
+        return nil, "", err
+    }
+    return body, resp.Header.Get("Content-Type"), nil
+}
+
+// pageFetchError means the page given to discoverFeeds couldn't be fetched
+// at all, as opposed to not linking to a feed.
+type pageFetchError struct {
+    Err error
+}
+
+func (e *pageFetchError) Error() string {
+    return e.Err.Error()
+}
+
+func (e *pageFetchError) Unwrap() error {
+    return e.Err
+}
+
+// isHTML reports whether the response is an HTML page, going by its content
+// type or, when the server didn't send one, by sniffing the body.
+func isHTML(body []byte, contentType string) bool {
+    if contentType == "" {
+        contentType = http.DetectContentType(body)
+    }
+    mediaType, _, err := mime.ParseMediaType(contentType)
+    if err != nil {
+        return false
+    }
+    return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+// isFeed reports whether the document parses as one of the feed formats
+// fetchFeed understands.
+func isFeed(body []byte, contentType string) bool {
+    if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType == "text/html" {
+        return false
+    }
+    body, err := toUTF8(body, contentType)
+    if err != nil {
+        return false
+    }
+    if isJSONFeed(body, contentType) {
+        _, err := parseFeed(body, contentType)
+        return err == nil
+    }
+
+    root, err := rootElement(body)
+    if err != nil {
+        return false
+    }
+    switch {
+    case root.Space == atomNamespace && root.Local == "feed":
+        return true
+    case root.Space == rdfNamespace && root.Local == "RDF":
+        return true
+    case root.Local == "rss":
+        return true
+    }
+    return false
+}
+
+// feedLinks returns the hrefs of <link rel="alternate"> tags with a feed
+// type, resolved against the page URL.
+func feedLinks(body []byte, base *url.URL) []string {
+    var links []string
+    seen := map[string]bool{}
+
+    for _, tag := range htmlLinkTag.FindAll(body, -1) {
+        attrs := map[string]string{}
+        for _, match := range htmlAttribute.FindAllSubmatch(tag, -1) {
+            value := html.UnescapeString(strings.Trim(string(match[2]), `"'`))
+            attrs[strings.ToLower(string(match[1]))] = value
+        }
+
+        rels := strings.Fields(strings.ToLower(attrs["rel"]))
+        alternate := false
+        for _, rel := range rels {
+            if rel == "alternate" {
+                alternate = true
+            }
+        }
+        if !alternate || !feedLinkTypes[strings.ToLower(strings.TrimSpace(attrs["type"]))] {
+            continue
+        }
+
+        href, err := url.Parse(strings.TrimSpace(attrs["href"]))
+        if err != nil || attrs["href"] == "" {
+            continue
+        }
+        link := base.ResolveReference(href).String()
+        if !seen[link] {
+            seen[link] = true
+            links = append(links, link)
+        }
+    }
+
+    return links
+}
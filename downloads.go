@@ -0,0 +1,291 @@
+package main
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "os"
+    "path"
+    "path/filepath"
+    "strings"
+    "time"
+    "unicode"
+
+    "github.com/google/uuid"
+    "github.com/DanielJacob1998/gator/internal/database"
+)
+
+const defaultKeepEpisodes = 5
+
+func handlerDownload(s *state, cmd command, user database.User) error {
+    if len(cmd.Args) != 0 {
+        return fmt.Errorf("usage: %s", cmd.Name)
+    }
+    ctx := context.Background()
+
+    dir, err := downloadDir(s)
+    if err != nil {
+        return err
+    }
+    keep := s.cfg.KeepEpisodes
+    if keep <= 0 {
+        keep = defaultKeepEpisodes
+    }
+
+    // Queue the newest episodes of every followed feed.
+    enclosureIDs, err := s.db.GetLatestEnclosuresForUser(ctx, database.GetLatestEnclosuresForUserParams{
+        UserID: user.ID,
+        Limit:  int32(keep),
+    })
+    if err != nil {
+        return fmt.Errorf("couldn't get latest episodes: %w", err)
+    }
+    for _, enclosureID := range enclosureIDs {
+        err := s.db.QueueDownload(ctx, database.QueueDownloadParams{
+            ID:          uuid.New(),
+            CreatedAt:   time.Now().UTC(),
+            UpdatedAt:   time.Now().UTC(),
+            UserID:      user.ID,
+            EnclosureID: enclosureID,
+        })
+        if err != nil {
+            return fmt.Errorf("couldn't queue download: %w", err)
+        }
+    }
+
+    // Clean up before downloading so expired episodes free their quota.
+    removed, err := removeExpiredDownloads(ctx, s.db, user, keep)
+    if err != nil {
+        return err
+    }
+
+    pending, err := s.db.GetPendingDownloadsForUser(ctx, user.ID)
+    if err != nil {
+        return fmt.Errorf("couldn't get pending downloads: %w", err)
+    }
+
+    quota := s.cfg.DownloadQuotaMB * 1000 * 1000
+    downloaded, failed, skipped := 0, 0, 0
+    for _, download := range pending {
+        // Zero means no limit. Episodes without a usable length still get
+        // cut off by downloadFile once they run over.
+        var remaining int64
+        if quota > 0 {
+            used, err := s.db.GetDownloadedBytesForUser(ctx, user.ID)
+            if err != nil {
+                return fmt.Errorf("couldn't get storage used: %w", err)
+            }
+            remaining = quota - used
+            if remaining <= 0 || download.SizeBytes.Int64 > remaining {
+                fmt.Printf("Skipping %s: storage quota reached\n", download.PostTitle)
+                skipped++
+                continue
+            }
+        }
+
+        dest := filepath.Join(dir, safeFileName(user.Name), safeFileName(download.FeedName), episodeFileName(download))
+        // Recorded up front so a partial file can be found again if the
+        // episode expires before it finishes
+        err := s.db.SetDownloadPath(ctx, database.SetDownloadPathParams{
+            ID: download.ID,
+            Path: sql.NullString{
+                String: dest,
+                Valid:  true,
+            },
+        })
+        if err != nil {
+            return fmt.Errorf("couldn't record download path: %w", err)
+        }
+        fmt.Printf("Downloading %s from %s...\n", download.PostTitle, download.FeedName)
+        size, err := downloadFile(ctx, s.client, download.EnclosureUrl, dest, remaining)
+        if err != nil {
+            fmt.Printf("Couldn't download %s: %v\n", download.PostTitle, err)
+            failed++
+            err = s.db.FailDownload(ctx, database.FailDownloadParams{
+                ID: download.ID,
+                Error: sql.NullString{
+                    String: err.Error(),
+                    Valid:  true,
+                },
+            })
+            if err != nil {
+                return fmt.Errorf("couldn't record failed download: %w", err)
+            }
+            continue
+        }
+
+        err = s.db.CompleteDownload(ctx, database.CompleteDownloadParams{
+            ID: download.ID,
+            Path: sql.NullString{
+                String: dest,
+                Valid:  true,
+            },
+            Bytes: size,
+        })
+        if err != nil {
+            return fmt.Errorf("couldn't record download: %w", err)
+        }
+        downloaded++
+    }
+
+    fmt.Printf("%d downloaded, %d failed, %d skipped, %d removed\n", downloaded, failed, skipped, removed)
+    return nil
+}
+
+func downloadDir(s *state) (string, error) {
+    if s.cfg.DownloadDir != "" {
+        return s.cfg.DownloadDir, nil
+    }
+    home, err := os.UserHomeDir()
+    if err != nil {
+        return "", err
+    }
+    return filepath.Join(home, "gator", "downloads"), nil
+}
+
+// removeExpiredDownloads deletes downloads, finished or not, of episodes
+// that are no longer among the newest keep episodes of their feed.
+func removeExpiredDownloads(ctx context.Context, db *database.Queries, user database.User, keep int) (int, error) {
+    expired, err := db.GetExpiredDownloadsForUser(ctx, database.GetExpiredDownloadsForUserParams{
+        UserID: user.ID,
+        Limit:  int32(keep),
+    })
+    if err != nil {
+        return 0, fmt.Errorf("couldn't get expired downloads: %w", err)
+    }
+
+    for _, download := range expired {
+        if download.Path.Valid {
+            if err := removeDownloadFiles(download.Path.String); err != nil {
+                return 0, err
+            }
+        }
+        if err := db.DeleteDownload(ctx, download.ID); err != nil {
+            return 0, fmt.Errorf("couldn't delete download: %w", err)
+        }
+    }
+    return len(expired), nil
+}
+
+var errQuotaExceeded = errors.New("episode doesn't fit in the storage quota")
+
+// removeDownloadFiles removes an episode at dest along with what
+// downloadFile may have left of it in dest.part.
+func removeDownloadFiles(dest string) error {
+    for _, name := range []string{dest, dest + ".part"} {
+        err := os.Remove(name)
+        if err != nil && !os.IsNotExist(err) {
+            return fmt.Errorf("couldn't remove %s: %w", name, err)
+        }
+    }
+    return nil
+}
+
+// downloadFile fetches mediaURL into dest. Data is written to dest.part first,
+// and an existing partial file is resumed with a range request. If the file
+// grows past maxBytes, unless that is zero, it is deleted and
+// errQuotaExceeded returned.
+func downloadFile(ctx context.Context, c *httpClient, mediaURL string, dest string, maxBytes int64) (int64, error) {
+    if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+        return 0, err
+    }
+
+    partial := dest + ".part"
+    file, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY, 0644)
+    if err != nil {
+        return 0, err
+    }
+    defer file.Close()
+
+    info, err := file.Stat()
+    if err != nil {
+        return 0, err
+    }
+    offset := info.Size()
+
+    req, err := c.newRequest(ctx, "GET", mediaURL, nil)
+    if err != nil {
+        return 0, err
+    }
+    if offset > 0 {
+        req.Header.Add("Range", fmt.Sprintf("bytes=%d-", offset))
+    }
+
+    resp, err := c.download(req)
+    if err != nil {
+        return 0, err
+    }
+    defer resp.Body.Close()
+
+    switch resp.StatusCode {
+    case http.StatusPartialContent:
+        if _, err := file.Seek(offset, io.SeekStart); err != nil {
+            return 0, err
+        }
+    case http.StatusOK:
+        // The server ignored the range, so start over.
+        if err := file.Truncate(0); err != nil {
+            return 0, err
+        }
+        offset = 0
+    case http.StatusRequestedRangeNotSatisfiable:
+        // The partial file already holds the whole episode.
+    default:
+        return 0, fmt.Errorf("unexpected status %s", resp.Status)
+    }
+
+    if resp.StatusCode != http.StatusRequestedRangeNotSatisfiable {
+        var body io.Reader = resp.Body
+        if maxBytes > 0 {
+            // One byte over is enough to know it doesn't fit
+            body = io.LimitReader(resp.Body, maxBytes-offset+1)
+        }
+        written, err := io.Copy(file, body)
+        if err != nil {
+            return 0, err
+        }
+        offset += written
+    }
+    if maxBytes > 0 && offset > maxBytes {
+        file.Close()
+        os.Remove(partial)
+        return 0, errQuotaExceeded
+    }
+
+    if err := file.Close(); err != nil {
+        return 0, err
+    }
+    if err := os.Rename(partial, dest); err != nil {
+        return 0, err
+    }
+    return offset, nil
+}
+
+func episodeFileName(download database.GetPendingDownloadsForUserRow) string {
+    ext := ".mp3"
+    if u, err := url.Parse(download.EnclosureUrl); err == nil && path.Ext(u.Path) != "" {
+        ext = path.Ext(u.Path)
+    }
+    return fmt.Sprintf("%s-%s%s", safeFileName(download.PostTitle), download.EnclosureID.String()[:8], ext)
+}
+
+func safeFileName(name string) string {
+    name = strings.Map(func(r rune) rune {
+        if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.' {
+            return r
+        }
+        return '_'
+    }, strings.TrimSpace(name))
+    name = strings.Trim(name, "._")
+    if runes := []rune(name); len(runes) > 100 {
+        name = string(runes[:100])
+    }
+    if name == "" {
+        return "untitled"
+    }
+    return name
+}
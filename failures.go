@@ -0,0 +1,133 @@
+package main
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "log"
+    "time"
+
+    "github.com/DanielJacob1998/gator/internal/database"
+)
+
+const (
+    defaultMaxFeedFailures = 10
+    // Failing feeds are retried after firstFailureBackoff, doubling with
+    // each further failure up to maxFailureBackoff.
+    firstFailureBackoff = 15 * time.Minute
+    maxFailureBackoff   = 48 * time.Hour
+)
+
+func recordFeedSuccess(ctx context.Context, s *state, feed database.Feed) {
+    if err := s.db.RecordFeedSuccess(ctx, feed.ID); err != nil {
+        log.Printf("Couldn't record success of feed %s: %v", feed.Name, err)
+    }
+}
+
+// recordFeedFailure counts a failed fetch, backs the feed off exponentially,
+// and disables it once it has failed too many times in a row, or right away
+// if the server says it is gone. A server asking us to come back later with
+// Retry-After gets at least that long.
+func recordFeedFailure(ctx context.Context, s *state, feed database.Feed, resp feedResponse, fetchErr error) {
+    updated, err := s.db.RecordFeedFailure(ctx, database.RecordFeedFailureParams{
+        ID: feed.ID,
+        LastError: sql.NullString{
+            String: fetchErr.Error(),
+            Valid:  true,
+        },
+    })
+    if err != nil {
+        log.Printf("Couldn't record failure of feed %s: %v", feed.Name, err)
+        return
+    }
+    failures := updated.ConsecutiveFailures
+
+    if errors.Is(fetchErr, errFeedGone) {
+        if err := s.db.DisableFeed(ctx, feed.ID); err != nil {
+            log.Printf("Couldn't disable feed %s: %v", feed.Name, err)
+            return
+        }
+        log.Printf("Disabled feed %s, the server says it is gone for good", feed.Name)
+        return
+    }
+    maxFailures := s.cfg.MaxFeedFailures
+    if maxFailures <= 0 {
+        maxFailures = defaultMaxFeedFailures
+    }
+    if int(failures) >= maxFailures {
+        if err := s.db.DisableFeed(ctx, feed.ID); err != nil {
+            log.Printf("Couldn't disable feed %s: %v", feed.Name, err)
+            return
+        }
+        log.Printf("Disabled feed %s after %d failures in a row", feed.Name, failures)
+        return
+    }
+
+    delay := max(failureBackoff(failures), resp.RetryAfter)
+    err = s.db.SetFeedNextFetch(ctx, database.SetFeedNextFetchParams{
+        ID:           feed.ID,
+        DelaySeconds: delay.Seconds(),
+    })
+    if err != nil {
+        log.Printf("Couldn't schedule retry of feed %s: %v", feed.Name, err)
+        return
+    }
+    log.Printf("Retrying feed %s in %s (%d failures in a row)", feed.Name, delay, failures)
+}
+
+func failureBackoff(failures int32) time.Duration {
+    delay := firstFailureBackoff
+    for i := int32(1); i < failures && delay < maxFailureBackoff; i++ {
+        delay *= 2
+    }
+    return min(delay, maxFailureBackoff)
+}
+
+func handlerBrokenFeeds(s *state) error {
+    feeds, err := s.db.GetBrokenFeeds(context.Background())
+    if err != nil {
+        return fmt.Errorf("couldn't get broken feeds: %w", err)
+    }
+    if len(feeds) == 0 {
+        fmt.Println("No broken feeds.")
+        return nil
+    }
+
+    for _, feed := range feeds {
+        status := "failing"
+        if feed.DisabledAt.Valid {
+            status = "disabled " + feed.DisabledAt.Time.Format(time.RFC1123)
+        }
+        fmt.Printf("Feed: %s (%s)\n", feed.Name, status)
+        fmt.Printf("URL: %s\n", feed.Url)
+        fmt.Printf("Failures in a row: %d\n", feed.ConsecutiveFailures)
+        if feed.LastError.Valid {
+            fmt.Printf("Last error: %s\n", feed.LastError.String)
+        }
+        if feed.LastSuccessAt.Valid {
+            fmt.Printf("Last success: %s\n", feed.LastSuccessAt.Time.Format(time.RFC1123))
+        } else {
+            fmt.Println("Last success: never")
+        }
+        fmt.Println()
+    }
+    return nil
+}
+
+func handlerReenableFeed(s *state, cmd command) error {
+    if len(cmd.Args) != 1 {
+        return fmt.Errorf("usage: %s <url>", cmd.Name)
+    }
+
+    feed, err := s.db.GetFeedByURL(context.Background(), cmd.Args[0])
+    if err != nil {
+        return fmt.Errorf("couldn't find feed: %w", err)
+    }
+    if err := s.db.ReenableFeed(context.Background(), feed.ID); err != nil {
+        return fmt.Errorf("couldn't re-enable feed: %w", err)
+    }
+
+    fmt.Printf("Feed %s re-enabled, it will be fetched on the next round\n", feed.Name)
+    return nil
+}
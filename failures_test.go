@@ -0,0 +1,28 @@
+package main
+
+import (
+    "testing"
+    "time"
+)
+
+func TestFailureBackoff(t *testing.T) {
+    tests := []struct {
+        failures int32
+        want     time.Duration
+    }{
+        {0, firstFailureBackoff},
+        {1, 15 * time.Minute},
+        {2, 30 * time.Minute},
+        {3, time.Hour},
+        {5, 4 * time.Hour},
+        {8, 32 * time.Hour},
+        {9, maxFailureBackoff},
+        {1000, maxFailureBackoff},
+    }
+
+    for _, tt := range tests {
+        if got := failureBackoff(tt.failures); got != tt.want {
+            t.Errorf("failureBackoff(%d) = %s, want %s", tt.failures, got, tt.want)
+        }
+    }
+}
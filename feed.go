@@ -1,66 +1,246 @@
 package main
 
 import (
+    "bytes"
     "context"
+    "encoding/json"
     "encoding/xml"
+    "errors"
+    "fmt"
     "net/http"
     "io"
+    "time"
     "html"
 )
 
 type RSSFeed struct {
     Channel struct {
-        Title       string    `xml:"title"`
-        Link        string    `xml:"link"`
-        Description string    `xml:"description"`
-        Item        []RSSItem `xml:"item"`
+        Title string `xml:"title"`
+        // atom:link must come before link, which would otherwise match it
+        AtomLinks   []AtomLink `xml:"http://www.w3.org/2005/Atom link"`
+        Link        string     `xml:"link"`
+        Description string     `xml:"description"`
+        Item        []RSSItem  `xml:"item"`
+        // Hints on how often the feed is worth polling
+        TTL             string   `xml:"ttl"`
+        UpdatePeriod    string   `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+        UpdateFrequency string   `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+        SkipHours       []string `xml:"skipHours>hour"`
+        SkipDays        []string `xml:"skipDays>day"`
     } `xml:"channel"`
 }
 
+// webSubLinks returns the WebSub hub the feed advertises and the topic URL
+// to subscribe to, which is empty if the feed doesn't name itself.
+func (f *RSSFeed) webSubLinks() (hub string, self string) {
+    for _, link := range f.Channel.AtomLinks {
+        switch link.Rel {
+        case "hub":
+            if hub == "" {
+                hub = link.Href
+            }
+        case "self":
+            if self == "" {
+                self = link.Href
+            }
+        }
+    }
+    return hub, self
+}
+
 type RSSItem struct {
-    Title       string `xml:"title"`
-    Link        string `xml:"link"`
-    Description string `xml:"description"`
-    PubDate     string `xml:"pubDate"`
+    Title       string         `xml:"title"`
+    Link        string         `xml:"link"`
+    Description string         `xml:"description"`
+    PubDate     string         `xml:"pubDate"`
+    GUID        string         `xml:"guid"`
+    Creator     string         `xml:"http://purl.org/dc/elements/1.1/ creator"`
+    Content     string         `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+    Enclosures  []RSSEnclosure `xml:"enclosure"`
+    Duration    string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
 }
 
-func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
-    req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+// errNotModified is returned by fetchFeed when the server answers a
+// conditional request with 304 Not Modified.
+var errNotModified = errors.New("feed not modified")
+
+// feedValidators are the cache validators a server sent with the last copy
+// of a feed, replayed on the next fetch as a conditional request.
+type feedValidators struct {
+    ETag         string
+    LastModified string
+}
+
+// errFeedGone is returned by fetchFeed when the server answers 410 Gone.
+var errFeedGone = errors.New("feed is gone")
+
+// feedResponse describes how the server answered a fetch, as far as it got.
+type feedResponse struct {
+    StatusCode int
+    Bytes      int
+    Validators feedValidators
+    // PermanentRedirect is the URL reached by the leading run of permanent
+    // redirects, before any temporary one. After a 301 then a 302 it is
+    // where the 301 pointed, not where the feed ended up.
+    PermanentRedirect string
+    // RetryAfter is how long a server answering 429 or 503 asked us to wait.
+    RetryAfter time.Duration
+}
+
+func fetchFeed(ctx context.Context, c *httpClient, feedURL string, validators feedValidators) (*RSSFeed, feedResponse, error) {
+    var result feedResponse
+
+    req, err := c.newRequest(ctx, "GET", feedURL, nil)
     if err != nil {
-        return nil, err
+        return nil, result, err
+    }
+    if validators.ETag != "" {
+        req.Header.Add("If-None-Match", validators.ETag)
+    }
+    if validators.LastModified != "" {
+        req.Header.Add("If-Modified-Since", validators.LastModified)
+    }
+    permanent := true
+    client := *c.Client
+    client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+        if len(via) >= 10 {
+            return errors.New("stopped after 10 redirects")
+        }
+        switch req.Response.StatusCode {
+        case http.StatusMovedPermanently, http.StatusPermanentRedirect:
+            if permanent {
+                result.PermanentRedirect = req.URL.String()
+            }
+        default:
+            permanent = false
+        }
+        return nil
     }
-    req.Header.Add("User-Agent", "gator")
-    client := &http.Client{}
 
     resp, err := client.Do(req)
     if err != nil {
-        return nil, err
+        return nil, result, err
     }
     defer resp.Body.Close()
+    result.StatusCode = resp.StatusCode
+
+    if resp.StatusCode == http.StatusNotModified {
+        result.Validators = validators
+        return nil, result, errNotModified
+    }
+    if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+        result.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+    }
+    if resp.StatusCode == http.StatusGone {
+        return nil, result, errFeedGone
+    }
+    if resp.StatusCode != http.StatusOK {
+        return nil, result, fmt.Errorf("unexpected status %s", resp.Status)
+    }
 
     // Read the body
     body, err := io.ReadAll(resp.Body)
+    result.Bytes = len(body)
+    if err != nil {
+        return nil, result, err
+    }
+
+    feed, err := decodeFeed(body, resp.Header.Get("Content-Type"))
+    if err != nil {
+        return nil, result, err
+    }
+
+    result.Validators = feedValidators{
+        ETag:         resp.Header.Get("ETag"),
+        LastModified: resp.Header.Get("Last-Modified"),
+    }
+    return feed, result, nil
+}
+
+// decodeFeed turns a feed document in any supported format and charset
+// into the RSS item model, with HTML entities in titles and descriptions
+// unescaped.
+func decodeFeed(body []byte, contentType string) (*RSSFeed, error) {
+    body, err := toUTF8(body, contentType)
     if err != nil {
         return nil, err
     }
 
-    // Create a feed struct to hold our data
-    var feed RSSFeed
+    // Parse the document into our struct
+    feed, err := parseFeed(body, contentType)
+    if err != nil {
+        return nil, err
+    }
 
-    // Parse the XML into our struct
-    err = xml.Unmarshal(body, &feed)
+    return feed, nil
+}
+
+// parseFeed looks at the content type and the root element of the document
+// to decide which format it is in, and returns its items in the RSS item model.
+func parseFeed(body []byte, contentType string) (*RSSFeed, error) {
+    if isJSONFeed(body, contentType) {
+        var jsonFeed JSONFeed
+        if err := json.Unmarshal(body, &jsonFeed); err != nil {
+            return nil, err
+        }
+        return jsonFeed.toRSS(), nil
+    }
+
+    root, err := rootElement(body)
     if err != nil {
         return nil, err
     }
 
+    if root.Space == atomNamespace && root.Local == "feed" {
+        var atom AtomFeed
+        if err := xml.Unmarshal(body, &atom); err != nil {
+            return nil, err
+        }
+        return atom.toRSS(), nil
+    }
+
+    if root.Space == rdfNamespace && root.Local == "RDF" {
+        var rdf RDFFeed
+        if err := xml.Unmarshal(body, &rdf); err != nil {
+            return nil, err
+        }
+        feed := rdf.toRSS()
+        unescapeRSSText(feed)
+        return feed, nil
+    }
+
+    var feed RSSFeed
+    if err := xml.Unmarshal(body, &feed); err != nil {
+        return nil, err
+    }
+    unescapeRSSText(&feed)
+    return &feed, nil
+}
+
+// unescapeRSSText unescapes HTML entities in the titles and descriptions of
+// RSS and RDF feeds, which are often escaped twice. Atom and JSON Feed say
+// exactly what their text is, and bodies that are already HTML are left
+// alone: unescaping them again would turn escaped code samples into real
+// tags.
+func unescapeRSSText(feed *RSSFeed) {
     feed.Channel.Title = html.UnescapeString(feed.Channel.Title)
     feed.Channel.Description = html.UnescapeString(feed.Channel.Description)
-
-    // Unescape HTML entities in each item
     for i := range feed.Channel.Item {
         feed.Channel.Item[i].Title = html.UnescapeString(feed.Channel.Item[i].Title)
         feed.Channel.Item[i].Description = html.UnescapeString(feed.Channel.Item[i].Description)
     }
+}
 
-    return &feed, nil
+func rootElement(body []byte) (xml.Name, error) {
+    decoder := xml.NewDecoder(bytes.NewReader(body))
+    for {
+        tok, err := decoder.Token()
+        if err != nil {
+            return xml.Name{}, err
+        }
+        if start, ok := tok.(xml.StartElement); ok {
+            return start.Name, nil
+        }
+    }
 }
+
@@ -2,13 +2,21 @@ package main
 
 import (
     "context"
+    "crypto/md5"
     "database/sql"
+    "encoding/hex"
+    "errors"
     "fmt"
+    "net/http"
     "time"
     "os"
+    "os/signal"
     "log"
-    "strings"
     "strconv"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "syscall"
     
     _ "github.com/lib/pq"
     "github.com/lib/pq"
@@ -103,8 +111,9 @@ func usersHandler(s *state, c command) error {
 }
 
 func handleAgg(s *state, cmd command) error {
-    if len(cmd.Args) < 1 || len(cmd.Args) > 2 {
-        return fmt.Errorf("usage: %v <time_between_reqs>", cmd.Name)
+    usage := fmt.Errorf("usage: %v <time_between_reqs> [--workers N]", cmd.Name)
+    if len(cmd.Args) < 1 {
+        return usage
     }
 
     timeBetweenRequests, err := time.ParseDuration(cmd.Args[0])
@@ -112,15 +121,88 @@ func handleAgg(s *state, cmd command) error {
         return fmt.Errorf("invalid duration: %w", err)
     }
 
-    log.Printf("Collecting feeds every %s...", timeBetweenRequests)
+    workers := 0
+    for i := 1; i < len(cmd.Args); i++ {
+        arg := cmd.Args[i]
+        value, ok := strings.CutPrefix(arg, "--workers=")
+        if !ok {
+            if arg != "--workers" || i+1 >= len(cmd.Args) {
+                return usage
+            }
+            i++
+            value = cmd.Args[i]
+        }
+        workers, err = strconv.Atoi(value)
+        if err != nil || workers < 1 {
+            return fmt.Errorf("invalid number of workers: %s", value)
+        }
+    }
+
+    hosts, err := newHostLimiter(s.cfg.HostRequestLimit, s.cfg.HostRequestInterval)
+    if err != nil {
+        return err
+    }
+    s.hosts = hosts
+
+    if workers > 0 {
+        log.Printf("Collecting up to %d feeds every %s...", workers, timeBetweenRequests)
+    } else {
+        log.Printf("Collecting feeds every %s...", timeBetweenRequests)
+    }
+
+    var webSubServer *http.Server
+    if webSubEnabled(s.cfg) {
+        webSubServer, err = serveWebSub(s)
+        if err != nil {
+            log.Printf("Couldn't start WebSub listener, polling only: %v", err)
+        }
+    }
 
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+    go func() {
+        // A second signal kills us the usual way
+        <-ctx.Done()
+        stop()
+        log.Println("Shutting down, waiting for feeds in progress...")
+    }()
+
+    stats := &aggStats{}
+    started := time.Now()
     ticker := time.NewTicker(timeBetweenRequests)
+    defer ticker.Stop()
+
+    for {
+        if workers > 0 {
+            scrapeFeedsConcurrently(ctx, s, workers, stats)
+        } else {
+            scrapeFeeds(ctx, s, stats)
+        }
+        if ctx.Err() == nil {
+            renewWebSubLeases(s)
+        }
 
-    for ; ; <-ticker.C {
-        scrapeFeeds(s)
+        select {
+        case <-ctx.Done():
+            if webSubServer != nil {
+                shutdownWebSub(webSubServer)
+            }
+            log.Printf("Stopped after %s: %d feeds collected, %d failed, %d posts stored",
+                time.Since(started).Round(time.Second), stats.feeds.Load(), stats.failed.Load(), stats.posts.Load())
+            return nil
+        case <-ticker.C:
+        }
     }
 }
 
+// aggStats counts what the aggregator did, for the summary it logs on
+// shutdown.
+type aggStats struct {
+    feeds  atomic.Int64
+    failed atomic.Int64
+    posts  atomic.Int64
+}
+
 func addfeed(db *sql.DB, name string, url string, userID uuid.UUID) error {
     ctx := context.Background()
     q := database.New(db)
@@ -178,15 +260,57 @@ func middlewareLoggedIn(handler func(s *state, cmd command, user database.User)
     }
 }
 
+// middlewareHTTPClient sets up the HTTP client for commands that make
+// requests, so a bad HTTP setting doesn't get in the way of the others.
+func middlewareHTTPClient(handler func(s *state, cmd command) error) func(*state, command) error {
+    return func(s *state, cmd command) error {
+        client, err := newHTTPClient(*s.cfg)
+        if err != nil {
+            return fmt.Errorf("error setting up HTTP client: %w", err)
+        }
+        s.client = client
+        return handler(s, cmd)
+    }
+}
+
 func handlerAddFeed(s *state, cmd command, user database.User) error {
     if len(cmd.Args) != 2 {
         return fmt.Errorf("usage: %s <name> <url>", cmd.Name)
     }
 
     name := cmd.Args[0]
-    url := cmd.Args[1]
+    url, err := discoverFeedURL(context.Background(), s.client, cmd.Args[1])
+    if err != nil {
+        return fmt.Errorf("couldn't find a feed at %s: %w", cmd.Args[1], err)
+    }
+
+    feed, feedFollow, err := createAndFollowFeed(s, user, name, url, sql.NullString{})
+    if err != nil {
+        return err
+    }
 
-    feed, err := s.db.CreateFeed(context.Background(), database.CreateFeedParams{
+    fmt.Println("Feed created successfully:")
+    printFeed(feed, user)
+    fmt.Println()
+    fmt.Println("Feed followed successfully:")
+    printFeedFollow(feedFollow.UserName, feedFollow.FeedName)
+    fmt.Println("=====================================")
+    return nil
+}
+
+// createAndFollowFeed adds a new feed and follows it for the user who added
+// it. Both happen in one transaction, so a failed follow doesn't leave behind
+// a feed nobody follows.
+func createAndFollowFeed(s *state, user database.User, name, url string, category sql.NullString) (database.Feed, database.CreateFeedFollowRow, error) {
+    ctx := context.Background()
+    tx, err := s.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return database.Feed{}, database.CreateFeedFollowRow{}, err
+    }
+    defer tx.Rollback()
+    q := s.db.WithTx(tx)
+
+    feed, err := q.CreateFeed(ctx, database.CreateFeedParams{
         ID:        uuid.New(),
         CreatedAt: time.Now().UTC(),
         UpdatedAt: time.Now().UTC(),
@@ -195,44 +319,73 @@ func handlerAddFeed(s *state, cmd command, user database.User) error {
         Url:       url,
     })
     if err != nil {
-        return fmt.Errorf("couldn't create feed: %w", err)
+        return database.Feed{}, database.CreateFeedFollowRow{}, fmt.Errorf("couldn't create feed: %w", err)
     }
 
-    feedFollow, err := s.db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
+    feedFollow, err := q.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
         ID:        uuid.New(),
         CreatedAt: time.Now().UTC(),
         UpdatedAt: time.Now().UTC(),
         UserID:    user.ID,
         FeedID:    feed.ID,
+        Category:  category,
     })
     if err != nil {
-        return fmt.Errorf("couldn't create feed follow: %w", err)
+        return database.Feed{}, database.CreateFeedFollowRow{}, fmt.Errorf("couldn't create feed follow: %w", err)
     }
 
-    fmt.Println("Feed created successfully:")
-    printFeed(feed, user)
-    fmt.Println()
-    fmt.Println("Feed followed successfully:")
-    printFeedFollow(feedFollow.UserName, feedFollow.FeedName)
-    fmt.Println("=====================================")
-    return nil
+    if err := tx.Commit(); err != nil {
+        return database.Feed{}, database.CreateFeedFollowRow{}, err
+    }
+    return feed, feedFollow, nil
 }
 
 func feedsHandler(s *state, c command) error {
+    if len(c.Args) == 1 && c.Args[0] == "--broken" {
+        return handlerBrokenFeeds(s)
+    }
+    if len(c.Args) != 0 {
+        return fmt.Errorf("usage: %s [--broken]", c.Name)
+    }
+
     feeds, err := s.db.GetAllFeeds(context.Background())
     if err != nil {
         return err
     }
 
     for _, feed := range feeds {
-        fmt.Printf("Feed: %s\nURL: %s\nCreated by: %s\n\n",
+        fmt.Printf("Feed: %s\nURL: %s\nCreated by: %s\n",
             feed.Name,
             feed.Url,
             feed.CreatorName)
+        if feed.FetchIntervalSeconds.Valid {
+            fmt.Printf("Fetch interval: %s\n", fetchInterval(feed.FetchIntervalSeconds))
+        }
+        if feed.NextFetchAt.Valid {
+            fmt.Printf("Next fetch: %s\n", feed.NextFetchAt.Time.Format(time.RFC1123))
+        }
+        fmt.Println()
     }
     return nil
 }
 
+// feedSubcommands are run as "feed <subcommand> [args...]".
+var feedSubcommands = map[string]func(*state, command) error{
+    "set-interval": handlerSetFeedInterval,
+    "reenable":     handlerReenableFeed,
+}
+
+func handlerFeed(s *state, cmd command) error {
+    if len(cmd.Args) < 1 {
+        return fmt.Errorf("usage: %s <subcommand> [args...]", cmd.Name)
+    }
+    f, ok := feedSubcommands[cmd.Args[0]]
+    if !ok {
+        return fmt.Errorf("unknown %s subcommand: %s", cmd.Name, cmd.Args[0])
+    }
+    return f(s, command{Name: cmd.Name + " " + cmd.Args[0], Args: cmd.Args[1:]})
+}
+
 func handleFollow(s *state, c command, user database.User) error {
     if len(c.Args) != 1 {
         return fmt.Errorf("follow command requires exactly 1 argument: URL")
@@ -242,6 +395,14 @@ func handleFollow(s *state, c command, user database.User) error {
     // Use the user parameter directly
     // Get feed by URL
     feed, err := s.db.GetFeedByURL(context.Background(), url)
+    if errors.Is(err, sql.ErrNoRows) {
+        // Maybe it's the site's homepage rather than the feed itself
+        feedURL, discoverErr := discoverFeedURL(context.Background(), s.client, url)
+        if discoverErr != nil {
+            return fmt.Errorf("error getting feed: %w", err)
+        }
+        feed, err = s.db.GetFeedByURL(context.Background(), feedURL)
+    }
     if err != nil {
         return fmt.Errorf("error getting feed: %w", err)
     }
@@ -312,59 +473,320 @@ func unfollowHandler(s *state, cmd command, user database.User) error {
     return nil
 }
 
-func scrapeFeeds(s *state) {
-    feed, err := s.db.GetNextFeedToFetch(context.Background())
+// feedClaimLease is how long a claimed feed stays reserved for the
+// aggregator that claimed it. Claims are released once the feed has been
+// scraped; the lease only matters if that aggregator dies mid-fetch.
+const feedClaimLease = 10 * time.Minute
+
+// claimFeeds reserves up to n of the feeds fetched longest ago. Rows being
+// claimed by another aggregator are skipped, so aggregators sharing a
+// database never scrape the same feed at the same time.
+func claimFeeds(ctx context.Context, s *state, n int) ([]database.Feed, error) {
+    return s.db.ClaimFeedsToFetch(ctx, database.ClaimFeedsToFetchParams{
+        LeaseSeconds: feedClaimLease.Seconds(),
+        BatchSize:    int32(n),
+    })
+}
+
+func scrapeFeeds(ctx context.Context, s *state, stats *aggStats) {
+    feeds, err := claimFeeds(ctx, s, 1)
     if err != nil {
         log.Println("Couldn't get next feeds to fetch", err)
         return
     }
+    if len(feeds) == 0 {
+        log.Println("No feeds to fetch")
+        return
+    }
     log.Println("Found a feed to fetch!")
-    scrapeFeed(s.db, feed)
+    scrapeFeed(ctx, s, feeds[0], stats)
+}
+
+// scrapeFeedsConcurrently claims the next batch of feeds, one per worker,
+// and fetches them in parallel. It returns once the whole batch is done.
+func scrapeFeedsConcurrently(ctx context.Context, s *state, workers int, stats *aggStats) {
+    feeds, err := claimFeeds(ctx, s, workers)
+    if err != nil {
+        log.Println("Couldn't get next feeds to fetch", err)
+        return
+    }
+    log.Printf("Found %d feeds to fetch!", len(feeds))
+
+    jobs := make(chan database.Feed)
+    var wg sync.WaitGroup
+    for i := 0; i < workers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for feed := range jobs {
+                scrapeFeed(ctx, s, feed, stats)
+            }
+        }()
+    }
+
+    for _, feed := range feeds {
+        jobs <- feed
+    }
+    close(jobs)
+    wg.Wait()
+}
+
+// resetFeedFetched undoes MarkFeedFetched for a feed whose fetch was cut
+// short by shutdown, so the next run picks it up straight away instead of
+// waiting out a full interval.
+func resetFeedFetched(s *state, feed database.Feed) {
+    err := s.db.ResetFeedFetched(context.Background(), database.ResetFeedFetchedParams{
+        ID:            feed.ID,
+        LastFetchedAt: feed.LastFetchedAt,
+    })
+    if err != nil {
+        log.Printf("Couldn't reset feed %s: %v", feed.Name, err)
+    }
 }
 
-func scrapeFeed(db *database.Queries, feed database.Feed) {
-    _, err := db.MarkFeedFetched(context.Background(), feed.ID)
+func scrapeFeed(ctx context.Context, s *state, feed database.Feed, stats *aggStats) {
+    defer func() {
+        if err := s.db.ReleaseFeed(context.Background(), feed.ID); err != nil {
+            log.Printf("Couldn't release feed %s: %v", feed.Name, err)
+        }
+    }()
+    // Claimed before shutdown began, leave it for the next run
+    if ctx.Err() != nil {
+        return
+    }
+
+    _, err := s.db.MarkFeedFetched(ctx, feed.ID)
     if err != nil {
         log.Printf("Couldn't mark feed %s fetched: %v", feed.Name, err)
+        stats.failed.Add(1)
         return
     }
 
-    feedData, err := fetchFeed(context.Background(), feed.Url)
+    pausedFor, err := s.hosts.wait(ctx, feed.Url)
+    if err != nil {
+        log.Printf("Stopped collecting feed %s", feed.Name)
+        resetFeedFetched(s, feed)
+        return
+    }
+    if pausedFor > 0 {
+        log.Printf("Host of feed %s asked us to back off, retrying in %s", feed.Name, pausedFor.Round(time.Second))
+        err = s.db.SetFeedNextFetch(ctx, database.SetFeedNextFetchParams{
+            ID:           feed.ID,
+            DelaySeconds: pausedFor.Seconds(),
+        })
+        if err != nil {
+            log.Printf("Couldn't schedule retry of feed %s: %v", feed.Name, err)
+        }
+        return
+    }
+
+    fetchedAt := time.Now().UTC()
+    feedData, resp, err := fetchFeed(ctx, s.client, feed.Url, feedValidators{
+        ETag:         feed.Etag.String,
+        LastModified: feed.LastModified.String,
+    })
+    if errors.Is(err, errNotModified) {
+        log.Printf("Feed %s not modified, no new posts", feed.Name)
+        logFetch(ctx, s, feed, fetchedAt, resp, 0, 0, nil)
+        recordFeedSuccess(ctx, s, feed)
+        scheduleNextFetch(ctx, s, feed, nil)
+        stats.feeds.Add(1)
+        recordRedirect(ctx, s, feed, resp.PermanentRedirect)
+        return
+    }
+    if ctx.Err() != nil {
+        log.Printf("Stopped collecting feed %s", feed.Name)
+        resetFeedFetched(s, feed)
+        return
+    }
     if err != nil {
         log.Printf("Couldn't collect feed %s: %v", feed.Name, err)
+        if resp.RetryAfter > 0 {
+            s.hosts.pause(feed.Url, resp.RetryAfter)
+        }
+        logFetch(ctx, s, feed, fetchedAt, resp, 0, 0, err)
+        recordFeedFailure(ctx, s, feed, resp, err)
+        stats.failed.Add(1)
         return
     }
-    for _, item := range feedData.Channel.Item {
-        publishedAt := sql.NullTime{}
-        if t, err := time.Parse(time.RFC1123Z, item.PubDate); err == nil {
-            publishedAt = sql.NullTime{
-                Time:  t,
-                Valid: true,
-            }
+
+    stored := storeItems(ctx, s, feed, feedData.Channel.Item, fetchedAt)
+    stats.posts.Add(int64(stored.Inserted + stored.Updated))
+    if ctx.Err() != nil {
+        // Keep the old validators so the next run gets the whole feed again
+        log.Printf("Stopped storing feed %s after %d posts", feed.Name, stored.Inserted+stored.Updated)
+        resetFeedFetched(s, feed)
+        return
+    }
+    logFetch(ctx, s, feed, fetchedAt, resp, len(feedData.Channel.Item), stored.Inserted, nil)
+
+    err = s.db.UpdateFeedValidators(ctx, database.UpdateFeedValidatorsParams{
+        ID: feed.ID,
+        Etag: sql.NullString{
+            String: resp.Validators.ETag,
+            Valid:  resp.Validators.ETag != "",
+        },
+        LastModified: sql.NullString{
+            String: resp.Validators.LastModified,
+            Valid:  resp.Validators.LastModified != "",
+        },
+    })
+    if err != nil {
+        log.Printf("Couldn't store cache validators for feed %s: %v", feed.Name, err)
+    }
+    recordFeedSuccess(ctx, s, feed)
+    scheduleNextFetch(ctx, s, feed, feedData)
+    log.Printf("Feed %s collected, %v posts found", feed.Name, len(feedData.Channel.Item))
+    stats.feeds.Add(1)
+
+    if hub, self := feedData.webSubLinks(); hub != "" {
+        subscribeIfNeeded(s, feed, hub, self)
+    }
+
+    // Last, as the feed may be merged into another one and deleted
+    recordRedirect(ctx, s, feed, resp.PermanentRedirect)
+}
+
+// storedPosts counts what storeItems wrote.
+type storedPosts struct {
+    Inserted int
+    Updated  int
+}
+
+// storeItems saves fetched or pushed items as posts of the feed. fetchedAt
+// stands in for items without a usable publication date. It stops early
+// once ctx is cancelled.
+func storeItems(ctx context.Context, s *state, feed database.Feed, items []RSSItem, fetchedAt time.Time) storedPosts {
+    var stored storedPosts
+    for _, item := range items {
+        if ctx.Err() != nil {
+            break
+        }
+        post, ok, err := storeItem(ctx, s, feed, item, fetchedAt)
+        if err != nil {
+            log.Printf("Couldn't store post %s: %v", item.Title, err)
+            continue
+        }
+        switch {
+        case !ok:
+        case post.RevisedAt.Valid:
+            stored.Updated++
+        default:
+            stored.Inserted++
         }
+    }
+    return stored
+}
+
+// storeItem saves a post along with its revision and enclosures in a single
+// transaction, so an interrupted run never leaves a post half-written. It
+// reports false if we already had the post unchanged.
+func storeItem(ctx context.Context, s *state, feed database.Feed, item RSSItem, fetchedAt time.Time) (database.Post, bool, error) {
+    // Fall back to when we saw the post so it still sorts sensibly
+    publishedAt := sql.NullTime{
+        Time:  fetchedAt,
+        Valid: true,
+    }
+    if t, err := parsePubDate(item.PubDate); err == nil {
+        publishedAt.Time = t
+    }
 
-        _, err = db.CreatePost(context.Background(), database.CreatePostParams{
-            ID:        uuid.New(),
-            CreatedAt: time.Now().UTC(),
-            UpdatedAt: time.Now().UTC(),
-            FeedID:    feed.ID,
-            Title:     item.Title,
-            Description: sql.NullString{
-                String: item.Description,
-                Valid:  true,
-            },
-            Url:         item.Link,
-            PublishedAt: publishedAt,
+    // Items without a guid are identified by their link instead
+    guid := item.GUID
+    if guid == "" {
+        guid = item.Link
+    }
+
+    description := sql.NullString{
+        String: item.Description,
+        Valid:  true,
+    }
+    content := sql.NullString{
+        String: item.Content,
+        Valid:  item.Content != "",
+    }
+    hash := postContentHash(item.Title, description.String, content.String)
+
+    tx, err := s.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return database.Post{}, false, err
+    }
+    defer tx.Rollback()
+    q := s.db.WithTx(tx)
+
+    // Posts stored before guids were tracked got their link as guid; move
+    // them over to the real one rather than storing the post again
+    if guid != item.Link {
+        err = q.AdoptLegacyPost(ctx, database.AdoptLegacyPostParams{
+            Guid:   guid,
+            FeedID: feed.ID,
+            Url:    item.Link,
         })
         if err != nil {
-            if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
-                continue
-            }
-            log.Printf("Couldn't create post: %v", err)
-            continue
+            return database.Post{}, false, fmt.Errorf("couldn't adopt legacy post: %w", err)
         }
     }
-    log.Printf("Feed %s collected, %v posts found", feed.Name, len(feedData.Channel.Item))
+
+    // Inserts new posts and rewrites ones whose content changed. Posts
+    // we already have unchanged come back as sql.ErrNoRows.
+    post, err := q.UpsertPost(ctx, database.UpsertPostParams{
+        ID:          uuid.New(),
+        CreatedAt:   time.Now().UTC(),
+        UpdatedAt:   time.Now().UTC(),
+        FeedID:      feed.ID,
+        Title:       item.Title,
+        Description: description,
+        Url:         item.Link,
+        PublishedAt: publishedAt,
+        Author: sql.NullString{
+            String: item.Creator,
+            Valid:  item.Creator != "",
+        },
+        Content:     content,
+        Guid:        guid,
+        ContentHash: hash,
+    })
+    if errors.Is(err, sql.ErrNoRows) {
+        return database.Post{}, false, nil
+    }
+    if err != nil {
+        return database.Post{}, false, err
+    }
+
+    err = q.CreatePostRevision(ctx, database.CreatePostRevisionParams{
+        ID:          uuid.New(),
+        CreatedAt:   time.Now().UTC(),
+        PostID:      post.ID,
+        Title:       post.Title,
+        Url:         post.Url,
+        Description: post.Description,
+        Content:     post.Content,
+        ContentHash: post.ContentHash,
+    })
+    if err != nil {
+        return database.Post{}, false, fmt.Errorf("couldn't record revision: %w", err)
+    }
+
+    if !post.RevisedAt.Valid {
+        if err := createEnclosures(ctx, s, q, post.ID, item); err != nil {
+            return database.Post{}, false, err
+        }
+    }
+
+    if err := tx.Commit(); err != nil {
+        return database.Post{}, false, err
+    }
+    if post.RevisedAt.Valid {
+        log.Printf("Post %s was updated", post.Title)
+    }
+    return post, true, nil
+}
+
+// postContentHash must stay in step with the hash the 013_post_revisions
+// migration computes for existing posts.
+func postContentHash(title, description, content string) string {
+    sum := md5.Sum([]byte(title + "\n" + description + "\n" + content))
+    return hex.EncodeToString(sum[:])
 }
 
 func handlerBrowse(s *state, cmd command, user database.User) error {
@@ -389,8 +811,26 @@ func handlerBrowse(s *state, cmd command, user database.User) error {
     for _, post := range posts {
         fmt.Printf("%s from %s\n", post.PublishedAt.Time.Format("Mon Jan 2"), post.FeedName)
         fmt.Printf("--- %s ---\n", post.Title)
-        fmt.Printf("    %v\n", post.Description.String)
+        if post.RevisedAt.Valid {
+            fmt.Printf("    (updated %s)\n", post.RevisedAt.Time.Format("Mon Jan 2 15:04"))
+        }
+        if post.Author.Valid {
+            fmt.Printf("    by %s\n", post.Author.String)
+        }
+        body := post.Description.String
+        if post.Content.Valid {
+            body = post.Content.String
+        }
+        fmt.Printf("    %v\n", body)
         fmt.Printf("Link: %s\n", post.Url)
+
+        enclosures, err := s.db.GetEnclosuresForPost(context.Background(), post.ID)
+        if err != nil {
+            return fmt.Errorf("couldn't get enclosures for post: %w", err)
+        }
+        for _, enclosure := range enclosures {
+            printEnclosure(enclosure)
+        }
         fmt.Println("=====================================")
     }
 
@@ -0,0 +1,114 @@
+package main
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "log"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/DanielJacob1998/gator/internal/database"
+)
+
+// defaultHealthWindow is how far back feed-health looks unless told otherwise.
+const defaultHealthWindow = 7 * 24 * time.Hour
+
+// logFetch records a fetch attempt in the fetch log. resp holds whatever
+// the server got to answer before fetchErr, if anything.
+func logFetch(ctx context.Context, s *state, feed database.Feed, startedAt time.Time, resp feedResponse, itemsSeen int, inserted int, fetchErr error) {
+    entry := database.CreateFetchLogParams{
+        ID:         uuid.New(),
+        FeedID:     feed.ID,
+        StartedAt:  startedAt,
+        FinishedAt: time.Now().UTC(),
+        StatusCode: sql.NullInt32{
+            Int32: int32(resp.StatusCode),
+            Valid: resp.StatusCode != 0,
+        },
+        Bytes: sql.NullInt32{
+            Int32: int32(resp.Bytes),
+            Valid: resp.StatusCode != 0,
+        },
+        ItemsSeen:     int32(itemsSeen),
+        PostsInserted: int32(inserted),
+    }
+    if fetchErr != nil {
+        entry.Error = sql.NullString{
+            String: fetchErr.Error(),
+            Valid:  true,
+        }
+    }
+
+    if err := s.db.CreateFetchLog(ctx, entry); err != nil {
+        log.Printf("Couldn't log fetch of feed %s: %v", feed.Name, err)
+    }
+}
+
+func handlerFeedHealth(s *state, cmd command) error {
+    if len(cmd.Args) > 1 {
+        return fmt.Errorf("usage: %s [window]", cmd.Name)
+    }
+    window := defaultHealthWindow
+    if len(cmd.Args) == 1 {
+        var err error
+        window, err = parseFetchInterval(cmd.Args[0])
+        if err != nil || window <= 0 {
+            return fmt.Errorf("invalid window: %s", cmd.Args[0])
+        }
+    }
+
+    now := time.Now().UTC()
+    feeds, err := s.db.GetFeedHealth(context.Background(), now.Add(-window))
+    if err != nil {
+        return fmt.Errorf("couldn't get feed health: %w", err)
+    }
+    if len(feeds) == 0 {
+        fmt.Println("No feeds found.")
+        return nil
+    }
+
+    fmt.Printf("Feed health over the last %s:\n\n", window)
+    for _, feed := range feeds {
+        fmt.Printf("Feed: %s\n", feed.Name)
+        fmt.Printf("URL: %s\n", feed.Url)
+        if feed.DisabledAt.Valid {
+            fmt.Printf("Disabled: %s\n", feed.DisabledAt.Time.Format(time.RFC1123))
+        }
+        if feed.Fetches == 0 {
+            fmt.Println("Fetches: none")
+        } else {
+            fmt.Printf("Fetches: %d, %.0f%% successful\n", feed.Fetches, 100*float64(feed.Successes)/float64(feed.Fetches))
+            fmt.Printf("Latency: %s average, %s slowest\n", seconds(feed.AvgSeconds), seconds(feed.MaxSeconds))
+        }
+        fmt.Printf("Last success: %s\n", ago(feed.LastSuccessAt, now))
+
+        // Staleness is about the content: a feed can fetch fine and still
+        // not have posted in months
+        newest, err := s.db.GetRecentPublishTimes(context.Background(), database.GetRecentPublishTimesParams{
+            FeedID: feed.ID,
+            Limit:  1,
+        })
+        if err != nil {
+            return fmt.Errorf("couldn't get newest post: %w", err)
+        }
+        if len(newest) == 0 {
+            fmt.Println("Newest post: none")
+        } else {
+            fmt.Printf("Newest post: %s\n", ago(newest[0], now))
+        }
+        fmt.Println()
+    }
+    return nil
+}
+
+func seconds(s float64) time.Duration {
+    return time.Duration(s * float64(time.Second)).Round(time.Millisecond)
+}
+
+func ago(t sql.NullTime, now time.Time) string {
+    if !t.Valid {
+        return "never"
+    }
+    return fmt.Sprintf("%s ago", now.Sub(t.Time).Round(time.Minute))
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+    "context"
+    "crypto/tls"
+    "crypto/x509"
+    "errors"
+    "fmt"
+    "io"
+    "net"
+    "net/http"
+    "net/url"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/DanielJacob1998/gator/internal/config"
+)
+
+const (
+    defaultUserAgent      = "gator"
+    defaultConnectTimeout = 10 * time.Second
+    defaultReadTimeout    = 30 * time.Second
+)
+
+// httpClient is the client every outgoing request goes through, set up from
+// the HTTP settings in the config. Requests made with it are bounded by the
+// read timeout as a whole; Downloads only bounds the wait for the response
+// headers, as episodes can take a long time to transfer. Use it through
+// download, which also gives up on a transfer that stalls.
+type httpClient struct {
+    *http.Client
+    Downloads   *http.Client
+    userAgent   string
+    readTimeout time.Duration
+}
+
+func newHTTPClient(cfg config.Config) (*httpClient, error) {
+    connectTimeout, err := configDuration(cfg.HTTPConnectTimeout, defaultConnectTimeout)
+    if err != nil {
+        return nil, fmt.Errorf("invalid HTTP connect timeout: %w", err)
+    }
+    readTimeout, err := configDuration(cfg.HTTPReadTimeout, defaultReadTimeout)
+    if err != nil {
+        return nil, fmt.Errorf("invalid HTTP read timeout: %w", err)
+    }
+
+    transport := http.DefaultTransport.(*http.Transport).Clone()
+    transport.DialContext = (&net.Dialer{
+        Timeout:   connectTimeout,
+        KeepAlive: 30 * time.Second,
+    }).DialContext
+    transport.TLSHandshakeTimeout = connectTimeout
+    transport.ResponseHeaderTimeout = readTimeout
+
+    if cfg.HTTPProxy != "" {
+        // net/http handles http, https and socks5 proxies alike
+        proxy, err := url.Parse(cfg.HTTPProxy)
+        if err != nil || proxy.Host == "" {
+            return nil, fmt.Errorf("invalid HTTP proxy: %s", cfg.HTTPProxy)
+        }
+        transport.Proxy = http.ProxyURL(proxy)
+    }
+
+    tlsConfig := &tls.Config{}
+    if cfg.CABundle != "" {
+        pem, err := os.ReadFile(cfg.CABundle)
+        if err != nil {
+            return nil, fmt.Errorf("couldn't read CA bundle: %w", err)
+        }
+        pool, err := x509.SystemCertPool()
+        if err != nil {
+            pool = x509.NewCertPool()
+        }
+        if !pool.AppendCertsFromPEM(pem) {
+            return nil, fmt.Errorf("no certificates found in CA bundle %s", cfg.CABundle)
+        }
+        tlsConfig.RootCAs = pool
+    }
+    transport.TLSClientConfig = tlsConfig
+
+    var roundTripper http.RoundTripper = transport
+    if len(cfg.InsecureSkipVerifyHosts) > 0 {
+        insecure := transport.Clone()
+        insecure.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+        hosts := make(map[string]bool)
+        for _, host := range cfg.InsecureSkipVerifyHosts {
+            hosts[strings.ToLower(host)] = true
+        }
+        roundTripper = &insecureHostsTransport{
+            secure:   transport,
+            insecure: insecure,
+            hosts:    hosts,
+        }
+    }
+
+    userAgent := cfg.UserAgent
+    if userAgent == "" {
+        userAgent = defaultUserAgent
+    }
+    if cfg.ContactURL != "" {
+        userAgent = fmt.Sprintf("%s (+%s)", userAgent, cfg.ContactURL)
+    }
+
+    return &httpClient{
+        Client: &http.Client{
+            Transport: roundTripper,
+            Timeout:   readTimeout,
+        },
+        Downloads: &http.Client{
+            Transport: roundTripper,
+        },
+        userAgent:   userAgent,
+        readTimeout: readTimeout,
+    }, nil
+}
+
+// insecureHostsTransport skips certificate checks for requests to the hosts
+// configured for it and verifies everything else as usual. Each redirect is
+// its own round trip, so a redirect away from those hosts is checked again.
+type insecureHostsTransport struct {
+    secure   *http.Transport
+    insecure *http.Transport
+    hosts    map[string]bool
+}
+
+func (t *insecureHostsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    if t.hosts[strings.ToLower(req.URL.Hostname())] {
+        return t.insecure.RoundTrip(req)
+    }
+    return t.secure.RoundTrip(req)
+}
+
+// errDownloadStalled is returned by the body of a download once no data has
+// arrived for the read timeout.
+var errDownloadStalled = errors.New("download stalled")
+
+// download sends req with the Downloads client. The transfer as a whole
+// isn't bounded, but it is cut off once the server stops sending for the
+// read timeout.
+func (c *httpClient) download(req *http.Request) (*http.Response, error) {
+    ctx, cancel := context.WithCancelCause(req.Context())
+    timer := time.AfterFunc(c.readTimeout, func() {
+        cancel(errDownloadStalled)
+    })
+
+    resp, err := c.Downloads.Do(req.WithContext(ctx))
+    if err != nil {
+        timer.Stop()
+        cancel(nil)
+        return nil, err
+    }
+    resp.Body = &idleTimeoutBody{
+        ReadCloser: resp.Body,
+        ctx:        ctx,
+        cancel:     cancel,
+        timer:      timer,
+        timeout:    c.readTimeout,
+    }
+    return resp, nil
+}
+
+// idleTimeoutBody pushes back the download's deadline whenever data arrives.
+type idleTimeoutBody struct {
+    io.ReadCloser
+    ctx     context.Context
+    cancel  context.CancelCauseFunc
+    timer   *time.Timer
+    timeout time.Duration
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+    n, err := b.ReadCloser.Read(p)
+    if n > 0 {
+        b.timer.Reset(b.timeout)
+    }
+    if err != nil && errors.Is(context.Cause(b.ctx), errDownloadStalled) {
+        err = errDownloadStalled
+    }
+    return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+    b.timer.Stop()
+    b.cancel(nil)
+    return b.ReadCloser.Close()
+}
+
+// newRequest is http.NewRequestWithContext with our User-Agent set.
+func (c *httpClient) newRequest(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error) {
+    req, err := http.NewRequestWithContext(ctx, method, url, body)
+    if err != nil {
+        return nil, err
+    }
+    req.Header.Set("User-Agent", c.userAgent)
+    return req, nil
+}
+
+func configDuration(value string, fallback time.Duration) (time.Duration, error) {
+    if value == "" {
+        return fallback, nil
+    }
+    d, err := time.ParseDuration(value)
+    if err != nil {
+        return 0, err
+    }
+    if d <= 0 {
+        return 0, fmt.Errorf("must be positive: %s", value)
+    }
+    return d, nil
+}
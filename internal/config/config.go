@@ -12,6 +12,42 @@ const defaultDBURL = "postgres://danielmariathasan@localhost:5432/gator?sslmode=
 type Config struct {
     CurrentUserName string `json:"current_user_name"`
     DatabaseURL     string `json:"database_url"`
+
+    // Podcast downloads. A zero quota means no limit.
+    DownloadDir        string `json:"download_dir,omitempty"`
+    DownloadQuotaMB    int64  `json:"download_quota_mb,omitempty"`
+    KeepEpisodes       int    `json:"keep_episodes,omitempty"`
+    AutoQueueDownloads bool   `json:"auto_queue_downloads,omitempty"`
+
+    // WebSub push. The callback URL is the public base URL hubs can reach
+    // the listener on; leaving it empty keeps agg polling only.
+    WebSubCallbackURL string `json:"websub_callback_url,omitempty"`
+    WebSubListenAddr  string `json:"websub_listen_addr,omitempty"`
+
+    // Feeds failing this many fetches in a row are disabled. Zero uses
+    // the default.
+    MaxFeedFailures int `json:"max_feed_failures,omitempty"`
+
+    // Politeness towards each host agg fetches from: at most
+    // HostRequestLimit requests per HostRequestInterval, a duration such
+    // as "10s". Zero values use the defaults.
+    HostRequestLimit    int    `json:"host_request_limit,omitempty"`
+    HostRequestInterval string `json:"host_request_interval,omitempty"`
+
+    // HTTP client for everything gator fetches. Timeouts are durations
+    // such as "10s". The proxy may be an http://, https:// or socks5://
+    // URL. The contact URL is added to the User-Agent so publishers can
+    // reach whoever runs this instance. The CA bundle is a PEM file of
+    // certificates to trust on top of the system ones.
+    HTTPConnectTimeout string `json:"http_connect_timeout,omitempty"`
+    HTTPReadTimeout    string `json:"http_read_timeout,omitempty"`
+    HTTPProxy          string `json:"http_proxy,omitempty"`
+    UserAgent          string `json:"user_agent,omitempty"`
+    ContactURL         string `json:"contact_url,omitempty"`
+    CABundle           string `json:"ca_bundle,omitempty"`
+    // Hosts whose certificates aren't checked at all. Only meant for
+    // internal feeds with self-signed certificates.
+    InsecureSkipVerifyHosts []string `json:"insecure_skip_verify_hosts,omitempty"`
 }
 
 func (cfg *Config) SetUser(username string) error {
@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -22,7 +23,7 @@ VALUES (
     $5,
     $6
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, claimed_until, fetch_interval_seconds, next_fetch_at, consecutive_failures, last_error, last_success_at, disabled_at, redirect_url, redirect_sightings, adaptive_interval_seconds
 `
 
 type AddFeedParams struct {
@@ -52,20 +53,34 @@ func (q *Queries) AddFeed(ctx context.Context, arg AddFeedParams) (Feed, error)
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.ClaimedUntil,
+		&i.FetchIntervalSeconds,
+		&i.NextFetchAt,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.DisabledAt,
+		&i.RedirectUrl,
+		&i.RedirectSightings,
+		&i.AdaptiveIntervalSeconds,
 	)
 	return i, err
 }
 
 const getAllFeeds = `-- name: GetAllFeeds :many
-SELECT feeds.name, feeds.url, users.name as creator_name
+SELECT feeds.name, feeds.url, feeds.fetch_interval_seconds, feeds.next_fetch_at, users.name as creator_name
 FROM feeds
 JOIN users ON feeds.user_id = users.id
 `
 
 type GetAllFeedsRow struct {
-	Name        string
-	Url         string
-	CreatorName string
+	Name                 string
+	Url                  string
+	FetchIntervalSeconds sql.NullInt32
+	NextFetchAt          sql.NullTime
+	CreatorName          string
 }
 
 func (q *Queries) GetAllFeeds(ctx context.Context) ([]GetAllFeedsRow, error) {
@@ -77,7 +92,13 @@ func (q *Queries) GetAllFeeds(ctx context.Context) ([]GetAllFeedsRow, error) {
 	var items []GetAllFeedsRow
 	for rows.Next() {
 		var i GetAllFeedsRow
-		if err := rows.Scan(&i.Name, &i.Url, &i.CreatorName); err != nil {
+		if err := rows.Scan(
+			&i.Name,
+			&i.Url,
+			&i.FetchIntervalSeconds,
+			&i.NextFetchAt,
+			&i.CreatorName,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -91,8 +112,39 @@ func (q *Queries) GetAllFeeds(ctx context.Context) ([]GetAllFeedsRow, error) {
 	return items, nil
 }
 
+const getFeedByID = `-- name: GetFeedByID :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, claimed_until, fetch_interval_seconds, next_fetch_at, consecutive_failures, last_error, last_success_at, disabled_at, redirect_url, redirect_sightings, adaptive_interval_seconds FROM feeds WHERE id = $1
+`
+
+func (q *Queries) GetFeedByID(ctx context.Context, id uuid.UUID) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByID, id)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.ClaimedUntil,
+		&i.FetchIntervalSeconds,
+		&i.NextFetchAt,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.DisabledAt,
+		&i.RedirectUrl,
+		&i.RedirectSightings,
+		&i.AdaptiveIntervalSeconds,
+	)
+	return i, err
+}
+
 const getFeedByURL = `-- name: GetFeedByURL :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds WHERE url = $1
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, claimed_until, fetch_interval_seconds, next_fetch_at, consecutive_failures, last_error, last_success_at, disabled_at, redirect_url, redirect_sightings, adaptive_interval_seconds FROM feeds WHERE url = $1
 `
 
 func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
@@ -106,6 +158,36 @@ func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.ClaimedUntil,
+		&i.FetchIntervalSeconds,
+		&i.NextFetchAt,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.DisabledAt,
+		&i.RedirectUrl,
+		&i.RedirectSightings,
+		&i.AdaptiveIntervalSeconds,
 	)
 	return i, err
 }
+
+const setFeedFetchInterval = `-- name: SetFeedFetchInterval :exec
+UPDATE feeds
+SET fetch_interval_seconds = $2,
+next_fetch_at = last_fetched_at + make_interval(secs => $2::integer),
+updated_at = NOW()
+WHERE id = $1
+`
+
+type SetFeedFetchIntervalParams struct {
+	ID                   uuid.UUID
+	FetchIntervalSeconds sql.NullInt32
+}
+
+func (q *Queries) SetFeedFetchInterval(ctx context.Context, arg SetFeedFetchIntervalParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFetchInterval, arg.ID, arg.FetchIntervalSeconds)
+	return err
+}
@@ -7,12 +7,76 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
+const claimFeedsToFetch = `-- name: ClaimFeedsToFetch :many
+UPDATE feeds
+SET claimed_until = NOW() + make_interval(secs => $1)
+WHERE id IN (
+    SELECT id FROM feeds
+    WHERE disabled_at IS NULL
+    AND (claimed_until IS NULL OR claimed_until < NOW())
+    AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
+    ORDER BY last_fetched_at ASC NULLS FIRST
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, claimed_until, fetch_interval_seconds, next_fetch_at, consecutive_failures, last_error, last_success_at, disabled_at, redirect_url, redirect_sightings, adaptive_interval_seconds
+`
+
+type ClaimFeedsToFetchParams struct {
+	LeaseSeconds float64
+	BatchSize    int32
+}
+
+func (q *Queries) ClaimFeedsToFetch(ctx context.Context, arg ClaimFeedsToFetchParams) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, claimFeedsToFetch, arg.LeaseSeconds, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.ClaimedUntil,
+			&i.FetchIntervalSeconds,
+			&i.NextFetchAt,
+			&i.ConsecutiveFailures,
+			&i.LastError,
+			&i.LastSuccessAt,
+			&i.DisabledAt,
+			&i.RedirectUrl,
+			&i.RedirectSightings,
+			&i.AdaptiveIntervalSeconds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, claimed_until, fetch_interval_seconds, next_fetch_at, consecutive_failures, last_error, last_success_at, disabled_at, redirect_url, redirect_sightings, adaptive_interval_seconds FROM feeds
 ORDER BY last_fetched_at ASC NULLS FIRST
 LIMIT 1
 `
@@ -28,6 +92,18 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.ClaimedUntil,
+		&i.FetchIntervalSeconds,
+		&i.NextFetchAt,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.DisabledAt,
+		&i.RedirectUrl,
+		&i.RedirectSightings,
+		&i.AdaptiveIntervalSeconds,
 	)
 	return i, err
 }
@@ -37,7 +113,7 @@ UPDATE feeds
 SET last_fetched_at = NOW(),
 updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, claimed_until, fetch_interval_seconds, next_fetch_at, consecutive_failures, last_error, last_success_at, disabled_at, redirect_url, redirect_sightings, adaptive_interval_seconds
 `
 
 func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) (Feed, error) {
@@ -51,6 +127,98 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) (Feed, erro
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.ClaimedUntil,
+		&i.FetchIntervalSeconds,
+		&i.NextFetchAt,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.DisabledAt,
+		&i.RedirectUrl,
+		&i.RedirectSightings,
+		&i.AdaptiveIntervalSeconds,
 	)
 	return i, err
 }
+
+const releaseFeed = `-- name: ReleaseFeed :exec
+UPDATE feeds
+SET claimed_until = NULL
+WHERE id = $1
+`
+
+func (q *Queries) ReleaseFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, releaseFeed, id)
+	return err
+}
+
+const resetFeedFetched = `-- name: ResetFeedFetched :exec
+UPDATE feeds
+SET last_fetched_at = $1,
+next_fetch_at = NULL
+WHERE id = $2
+`
+
+type ResetFeedFetchedParams struct {
+	LastFetchedAt sql.NullTime
+	ID            uuid.UUID
+}
+
+func (q *Queries) ResetFeedFetched(ctx context.Context, arg ResetFeedFetchedParams) error {
+	_, err := q.db.ExecContext(ctx, resetFeedFetched, arg.LastFetchedAt, arg.ID)
+	return err
+}
+
+const scheduleFeedFetch = `-- name: ScheduleFeedFetch :exec
+UPDATE feeds
+SET next_fetch_at = NOW() + make_interval(secs => $1),
+adaptive_interval_seconds = $2
+WHERE id = $3
+`
+
+type ScheduleFeedFetchParams struct {
+	DelaySeconds            float64
+	AdaptiveIntervalSeconds sql.NullInt32
+	ID                      uuid.UUID
+}
+
+func (q *Queries) ScheduleFeedFetch(ctx context.Context, arg ScheduleFeedFetchParams) error {
+	_, err := q.db.ExecContext(ctx, scheduleFeedFetch, arg.DelaySeconds, arg.AdaptiveIntervalSeconds, arg.ID)
+	return err
+}
+
+const setFeedNextFetch = `-- name: SetFeedNextFetch :exec
+UPDATE feeds
+SET next_fetch_at = NOW() + make_interval(secs => $1)
+WHERE id = $2
+`
+
+type SetFeedNextFetchParams struct {
+	DelaySeconds float64
+	ID           uuid.UUID
+}
+
+func (q *Queries) SetFeedNextFetch(ctx context.Context, arg SetFeedNextFetchParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedNextFetch, arg.DelaySeconds, arg.ID)
+	return err
+}
+
+const updateFeedValidators = `-- name: UpdateFeedValidators :exec
+UPDATE feeds
+SET etag = $2,
+last_modified = $3
+WHERE id = $1
+`
+
+type UpdateFeedValidatorsParams struct {
+	ID           uuid.UUID
+	Etag         sql.NullString
+	LastModified sql.NullString
+}
+
+func (q *Queries) UpdateFeedValidators(ctx context.Context, arg UpdateFeedValidatorsParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedValidators, arg.ID, arg.Etag, arg.LastModified)
+	return err
+}
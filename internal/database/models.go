@@ -11,14 +11,50 @@ import (
 	"github.com/google/uuid"
 )
 
+type Download struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	EnclosureID uuid.UUID
+	Status      string
+	Path        sql.NullString
+	Bytes       int64
+	Error       sql.NullString
+	CompletedAt sql.NullTime
+}
+
+type Enclosure struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	PostID          uuid.UUID
+	Url             string
+	MimeType        sql.NullString
+	SizeBytes       sql.NullInt64
+	DurationSeconds sql.NullInt32
+}
+
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID                      uuid.UUID
+	CreatedAt               time.Time
+	UpdatedAt               time.Time
+	Name                    string
+	Url                     string
+	UserID                  uuid.UUID
+	LastFetchedAt           sql.NullTime
+	Etag                    sql.NullString
+	LastModified            sql.NullString
+	ClaimedUntil            sql.NullTime
+	FetchIntervalSeconds    sql.NullInt32
+	NextFetchAt             sql.NullTime
+	ConsecutiveFailures     int32
+	LastError               sql.NullString
+	LastSuccessAt           sql.NullTime
+	DisabledAt              sql.NullTime
+	RedirectUrl             sql.NullString
+	RedirectSightings       int32
+	AdaptiveIntervalSeconds sql.NullInt32
 }
 
 type FeedFollow struct {
@@ -27,6 +63,19 @@ type FeedFollow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Category  sql.NullString
+}
+
+type FetchLog struct {
+	ID            uuid.UUID
+	FeedID        uuid.UUID
+	StartedAt     time.Time
+	FinishedAt    time.Time
+	StatusCode    sql.NullInt32
+	Bytes         sql.NullInt32
+	ItemsSeen     int32
+	PostsInserted int32
+	Error         sql.NullString
 }
 
 type Post struct {
@@ -38,6 +87,22 @@ type Post struct {
 	Description sql.NullString
 	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
+	Author      sql.NullString
+	Content     sql.NullString
+	Guid        string
+	ContentHash string
+	RevisedAt   sql.NullTime
+}
+
+type PostRevision struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	PostID      uuid.UUID
+	Title       string
+	Url         string
+	Description sql.NullString
+	Content     sql.NullString
+	ContentHash string
 }
 
 type User struct {
@@ -46,3 +111,15 @@ type User struct {
 	UpdatedAt time.Time
 	Name      string
 }
+
+type WebsubSubscription struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	FeedID         uuid.UUID
+	HubUrl         string
+	TopicUrl       string
+	Secret         string
+	Status         string
+	LeaseExpiresAt sql.NullTime
+}
@@ -6,6 +6,7 @@ import (
     "log"
     "os"
     "context"
+    "sync/atomic"
     
     _ "github.com/lib/pq"
     "github.com/DanielJacob1998/gator/internal/config"
@@ -13,8 +14,14 @@ import (
 )
 
 type state struct {
-    db  *database.Queries
-    cfg *config.Config
+    db     *database.Queries
+    conn   *sql.DB
+    cfg    *config.Config
+    // client is only set for commands wrapped in middlewareHTTPClient
+    client *httpClient
+    hosts  *hostLimiter
+    // webSubListening is set while the WebSub callback listener is up
+    webSubListening atomic.Bool
 }
 
 func main() {
@@ -35,8 +42,9 @@ func main() {
     dbQueries := database.New(db)
 
     programState := &state{
-        cfg: &cfg,
-        db:  dbQueries,
+        cfg:  &cfg,
+        db:   dbQueries,
+        conn: db,
     }
 
     // Create a new context for your operations
@@ -48,13 +56,18 @@ func main() {
     cmds.register("login", handlerLogin)
     cmds.register("register", handlerRegister)
     cmds.register("users", usersHandler)
-    cmds.register("addfeed", middlewareLoggedIn(handlerAddFeed))
-    cmds.register("agg", handleAgg)
-    cmds.register("follow", middlewareLoggedIn(handleFollow))
+    cmds.register("addfeed", middlewareHTTPClient(middlewareLoggedIn(handlerAddFeed)))
+    cmds.register("agg", middlewareHTTPClient(handleAgg))
+    cmds.register("follow", middlewareHTTPClient(middlewareLoggedIn(handleFollow)))
     cmds.register("following", middlewareLoggedIn(followingCommand))
     cmds.register("feeds", feedsHandler)
+    cmds.register("feed", handlerFeed)
+    cmds.register("feed-health", handlerFeedHealth)
     cmds.register("unfollow", middlewareLoggedIn(handlerUnfollow))
     cmds.register("browse", middlewareLoggedIn(handlerBrowse))
+    cmds.register("download", middlewareHTTPClient(middlewareLoggedIn(handlerDownload)))
+    cmds.register("import-opml", middlewareHTTPClient(middlewareLoggedIn(handlerImportOPML)))
+    cmds.register("export-opml", middlewareLoggedIn(handlerExportOPML))
     cmds.register("reset", handlerReset)
 
     cmdName := os.Args[1]
@@ -0,0 +1,226 @@
+package main
+
+import (
+    "context"
+    "database/sql"
+    "encoding/xml"
+    "errors"
+    "fmt"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/lib/pq"
+    "github.com/DanielJacob1998/gator/internal/database"
+)
+
+type OPML struct {
+    XMLName xml.Name `xml:"opml"`
+    Version string   `xml:"version,attr"`
+    Head    OPMLHead `xml:"head"`
+    Body    OPMLBody `xml:"body"`
+}
+
+type OPMLHead struct {
+    Title       string `xml:"title"`
+    DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type OPMLBody struct {
+    Outlines []OPMLOutline `xml:"outline"`
+}
+
+// OPMLOutline is either a feed, when XMLURL is set, or a folder holding
+// further outlines.
+type OPMLOutline struct {
+    Text     string        `xml:"text,attr"`
+    Title    string        `xml:"title,attr,omitempty"`
+    Type     string        `xml:"type,attr,omitempty"`
+    XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+    HTMLURL  string        `xml:"htmlUrl,attr,omitempty"`
+    Outlines []OPMLOutline `xml:"outline"`
+}
+
+// opmlFeed is a feed outline along with the folders it was nested in,
+// joined with "/".
+type opmlFeed struct {
+    Outline  OPMLOutline
+    Category string
+}
+
+// opmlFeeds flattens the outline tree into the outlines that are feeds,
+// however deeply they are nested in folders.
+func opmlFeeds(outlines []OPMLOutline, category string) []opmlFeed {
+    var feeds []opmlFeed
+    for _, outline := range outlines {
+        if outline.XMLURL != "" {
+            feeds = append(feeds, opmlFeed{Outline: outline, Category: category})
+            continue
+        }
+
+        folder := outline.Title
+        if folder == "" {
+            folder = outline.Text
+        }
+        if category != "" {
+            folder = category + "/" + folder
+        }
+        feeds = append(feeds, opmlFeeds(outline.Outlines, folder)...)
+    }
+    return feeds
+}
+
+// addToFolder appends outline to the folder at path, creating folders
+// as needed.
+func addToFolder(outlines []OPMLOutline, path []string, outline OPMLOutline) []OPMLOutline {
+    if len(path) == 0 {
+        return append(outlines, outline)
+    }
+    for i := range outlines {
+        if outlines[i].XMLURL == "" && outlines[i].Text == path[0] {
+            outlines[i].Outlines = addToFolder(outlines[i].Outlines, path[1:], outline)
+            return outlines
+        }
+    }
+    folder := OPMLOutline{Text: path[0]}
+    folder.Outlines = addToFolder(nil, path[1:], outline)
+    return append(outlines, folder)
+}
+
+func handlerImportOPML(s *state, cmd command, user database.User) error {
+    if len(cmd.Args) != 1 {
+        return fmt.Errorf("usage: %s <file>", cmd.Name)
+    }
+
+    data, err := os.ReadFile(cmd.Args[0])
+    if err != nil {
+        return fmt.Errorf("couldn't read OPML file: %w", err)
+    }
+    data, err = toUTF8(data, "")
+    if err != nil {
+        return fmt.Errorf("couldn't decode OPML file: %w", err)
+    }
+
+    var opml OPML
+    if err := xml.Unmarshal(data, &opml); err != nil {
+        return fmt.Errorf("couldn't parse OPML file: %w", err)
+    }
+
+    created, existing, failed := 0, 0, 0
+    for _, entry := range opmlFeeds(opml.Body.Outlines, "") {
+        outline := entry.Outline
+        category := sql.NullString{
+            String: entry.Category,
+            Valid:  entry.Category != "",
+        }
+        name := outline.Title
+        if name == "" {
+            name = outline.Text
+        }
+        if name == "" {
+            name = outline.XMLURL
+        }
+
+        feed, err := s.db.GetFeedByURL(context.Background(), outline.XMLURL)
+        if errors.Is(err, sql.ErrNoRows) {
+            // Same as addfeed, the outline may point at the site rather
+            // than its feed
+            var feedURL string
+            feedURL, err = discoverFeedURL(context.Background(), s.client, outline.XMLURL)
+            if err != nil {
+                fmt.Printf("Failed %s: couldn't find a feed: %v\n", outline.XMLURL, err)
+                failed++
+                continue
+            }
+            feed, err = s.db.GetFeedByURL(context.Background(), feedURL)
+            if errors.Is(err, sql.ErrNoRows) {
+                if _, _, err := createAndFollowFeed(s, user, name, feedURL, category); err != nil {
+                    fmt.Printf("Failed %s: %v\n", outline.XMLURL, err)
+                    failed++
+                    continue
+                }
+                fmt.Printf("Created %s\n", name)
+                created++
+                continue
+            }
+        }
+        if err != nil {
+            fmt.Printf("Failed %s: %v\n", outline.XMLURL, err)
+            failed++
+            continue
+        }
+
+        _, err = s.db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
+            ID:        uuid.New(),
+            CreatedAt: time.Now().UTC(),
+            UpdatedAt: time.Now().UTC(),
+            UserID:    user.ID,
+            FeedID:    feed.ID,
+            Category:  category,
+        })
+        // Already following the feed is fine
+        if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+            err = nil
+        }
+        if err != nil {
+            fmt.Printf("Failed %s: %v\n", outline.XMLURL, err)
+            failed++
+            continue
+        }
+        fmt.Printf("Already exists %s\n", feed.Name)
+        existing++
+    }
+
+    fmt.Printf("Imported OPML: %d created, %d already existing, %d failed\n", created, existing, failed)
+    return nil
+}
+
+func handlerExportOPML(s *state, cmd command, user database.User) error {
+    if len(cmd.Args) > 1 {
+        return fmt.Errorf("usage: %s [file]", cmd.Name)
+    }
+
+    follows, err := s.db.GetFeedFollowsForUser(context.Background(), user.ID)
+    if err != nil {
+        return fmt.Errorf("couldn't get feed follows: %w", err)
+    }
+
+    opml := OPML{
+        Version: "2.0",
+        Head: OPMLHead{
+            Title:       fmt.Sprintf("%s's gator subscriptions", user.Name),
+            DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+        },
+    }
+    for _, follow := range follows {
+        outline := OPMLOutline{
+            Text:   follow.FeedName,
+            Title:  follow.FeedName,
+            Type:   "rss",
+            XMLURL: follow.FeedUrl,
+        }
+        var path []string
+        if follow.Category.Valid {
+            path = strings.Split(follow.Category.String, "/")
+        }
+        opml.Body.Outlines = addToFolder(opml.Body.Outlines, path, outline)
+    }
+
+    data, err := xml.MarshalIndent(opml, "", "  ")
+    if err != nil {
+        return fmt.Errorf("couldn't encode OPML: %w", err)
+    }
+    data = append([]byte(xml.Header), data...)
+    data = append(data, '\n')
+
+    if len(cmd.Args) == 0 {
+        _, err = os.Stdout.Write(data)
+        return err
+    }
+    if err := os.WriteFile(cmd.Args[0], data, 0644); err != nil {
+        return fmt.Errorf("couldn't write OPML file: %w", err)
+    }
+    fmt.Printf("Exported %d feeds to %s\n", len(follows), cmd.Args[0])
+    return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/DanielJacob1998/gator/internal/database"
+)
+
+type RSSEnclosure struct {
+    URL    string `xml:"url,attr"`
+    Length string `xml:"length,attr"`
+    Type   string `xml:"type,attr"`
+}
+
+// parseItunesDuration reads itunes:duration, which is either a number of
+// seconds or an [[HH:]MM:]SS clock value.
+func parseItunesDuration(value string) (int32, bool) {
+    value = strings.TrimSpace(value)
+    if value == "" {
+        return 0, false
+    }
+
+    var seconds int64
+    for _, part := range strings.Split(value, ":") {
+        n, err := strconv.ParseFloat(part, 64)
+        if err != nil || n < 0 {
+            return 0, false
+        }
+        seconds = seconds*60 + int64(n)
+    }
+    return int32(seconds), true
+}
+
+// createEnclosures stores the item's enclosures through q and, if
+// auto-queueing is turned on, queues them for download by everyone
+// following the feed.
+func createEnclosures(ctx context.Context, s *state, q *database.Queries, postID uuid.UUID, item RSSItem) error {
+    duration, hasDuration := parseItunesDuration(item.Duration)
+
+    for _, enclosure := range item.Enclosures {
+        if enclosure.URL == "" {
+            continue
+        }
+        size, err := strconv.ParseInt(strings.TrimSpace(enclosure.Length), 10, 64)
+        hasSize := err == nil && size > 0
+
+        created, err := q.CreateEnclosure(ctx, database.CreateEnclosureParams{
+            ID:        uuid.New(),
+            CreatedAt: time.Now().UTC(),
+            UpdatedAt: time.Now().UTC(),
+            PostID:    postID,
+            Url:       enclosure.URL,
+            MimeType: sql.NullString{
+                String: enclosure.Type,
+                Valid:  enclosure.Type != "",
+            },
+            SizeBytes: sql.NullInt64{
+                Int64: size,
+                Valid: hasSize,
+            },
+            DurationSeconds: sql.NullInt32{
+                Int32: duration,
+                Valid: hasDuration,
+            },
+        })
+        // The item listed the same enclosure twice
+        if errors.Is(err, sql.ErrNoRows) {
+            continue
+        }
+        if err != nil {
+            return fmt.Errorf("couldn't create enclosure %s: %w", enclosure.URL, err)
+        }
+
+        if s.cfg.AutoQueueDownloads {
+            if err := q.QueueEnclosureForFollowers(ctx, created.ID); err != nil {
+                return fmt.Errorf("couldn't queue enclosure %s: %w", enclosure.URL, err)
+            }
+        }
+    }
+    return nil
+}
+
+func printEnclosure(enclosure database.Enclosure) {
+    details := []string{}
+    if enclosure.MimeType.Valid {
+        details = append(details, enclosure.MimeType.String)
+    }
+    if enclosure.SizeBytes.Valid {
+        details = append(details, fmt.Sprintf("%.1f MB", float64(enclosure.SizeBytes.Int64)/1e6))
+    }
+    if enclosure.DurationSeconds.Valid {
+        details = append(details, (time.Duration(enclosure.DurationSeconds.Int32) * time.Second).String())
+    }
+
+    if len(details) == 0 {
+        fmt.Printf("Enclosure: %s\n", enclosure.Url)
+        return
+    }
+    fmt.Printf("Enclosure: %s (%s)\n", enclosure.Url, strings.Join(details, ", "))
+}
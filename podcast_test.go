@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestParseItunesDuration(t *testing.T) {
+    tests := []struct {
+        value  string
+        want   int32
+        wantOK bool
+    }{
+        {"3723", 3723, true},
+        {"62:03", 3723, true},
+        {"1:02:03", 3723, true},
+        {"01:02:03", 3723, true},
+        {" 45 ", 45, true},
+        {"90.5", 90, true},
+        {"", 0, false},
+        {"abc", 0, false},
+        {"1:xx", 0, false},
+        {"-5", 0, false},
+    }
+
+    for _, tt := range tests {
+        got, ok := parseItunesDuration(tt.value)
+        if got != tt.want || ok != tt.wantOK {
+            t.Errorf("parseItunesDuration(%q) = %d, %v, want %d, %v", tt.value, got, ok, tt.want, tt.wantOK)
+        }
+    }
+}
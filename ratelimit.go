@@ -0,0 +1,160 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+const (
+    defaultHostRequestLimit    = 1
+    defaultHostRequestInterval = 2 * time.Second
+    // maxRetryAfter caps how long a Retry-After header can keep us away.
+    maxRetryAfter = 7 * 24 * time.Hour
+)
+
+// hostLimiter spaces out requests to the same host, so parallel workers
+// don't descend on a site hosting many of our feeds all at once. A nil
+// hostLimiter doesn't limit anything.
+type hostLimiter struct {
+    limit    int
+    interval time.Duration
+
+    mu    sync.Mutex
+    hosts map[string]*hostRequests
+}
+
+type hostRequests struct {
+    // recent holds when the requests made within the last interval started
+    recent      []time.Time
+    pausedUntil time.Time
+}
+
+func newHostLimiter(limit int, interval string) (*hostLimiter, error) {
+    l := &hostLimiter{
+        limit:    limit,
+        interval: defaultHostRequestInterval,
+        hosts:    map[string]*hostRequests{},
+    }
+    if l.limit <= 0 {
+        l.limit = defaultHostRequestLimit
+    }
+    if interval != "" {
+        d, err := time.ParseDuration(interval)
+        if err != nil || d <= 0 {
+            return nil, fmt.Errorf("invalid host request interval: %s", interval)
+        }
+        l.interval = d
+    }
+    return l, nil
+}
+
+// wait blocks until a request to the host of rawURL is allowed, or ctx is
+// done. If the host asked us to back off, wait doesn't block but returns
+// how much longer that lasts.
+func (l *hostLimiter) wait(ctx context.Context, rawURL string) (time.Duration, error) {
+    if l == nil {
+        return 0, nil
+    }
+    host := urlHost(rawURL)
+
+    for {
+        delay, paused := l.reserve(host, time.Now())
+        if paused {
+            return delay, nil
+        }
+        if delay <= 0 {
+            return 0, nil
+        }
+        timer := time.NewTimer(delay)
+        select {
+        case <-ctx.Done():
+            timer.Stop()
+            return 0, ctx.Err()
+        case <-timer.C:
+        }
+    }
+}
+
+// reserve takes a request slot for host if one is free and returns zero, or
+// returns how long to wait before trying again and whether that is because
+// the host is paused.
+func (l *hostLimiter) reserve(host string, now time.Time) (time.Duration, bool) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    h, ok := l.hosts[host]
+    if !ok {
+        h = &hostRequests{}
+        l.hosts[host] = h
+    }
+    if now.Before(h.pausedUntil) {
+        return h.pausedUntil.Sub(now), true
+    }
+
+    recent := h.recent[:0]
+    for _, t := range h.recent {
+        if now.Sub(t) < l.interval {
+            recent = append(recent, t)
+        }
+    }
+    h.recent = recent
+
+    if len(h.recent) >= l.limit {
+        return h.recent[0].Add(l.interval).Sub(now), false
+    }
+    h.recent = append(h.recent, now)
+    return 0, false
+}
+
+// pause holds off all requests to the host of rawURL for d, after it told
+// us to back off.
+func (l *hostLimiter) pause(rawURL string, d time.Duration) {
+    if l == nil {
+        return
+    }
+    host := urlHost(rawURL)
+
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    h, ok := l.hosts[host]
+    if !ok {
+        h = &hostRequests{}
+        l.hosts[host] = h
+    }
+    if until := time.Now().Add(d); until.After(h.pausedUntil) {
+        h.pausedUntil = until
+    }
+}
+
+func urlHost(rawURL string) string {
+    u, err := url.Parse(rawURL)
+    if err != nil {
+        return rawURL
+    }
+    return strings.ToLower(u.Hostname())
+}
+
+// parseRetryAfter reads a Retry-After header, which is either a number of
+// seconds or an HTTP date. It returns zero if the header is missing or
+// unusable.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+    value = strings.TrimSpace(value)
+    if value == "" {
+        return 0
+    }
+
+    var d time.Duration
+    if seconds, err := strconv.Atoi(value); err == nil {
+        // Capped first, a huge value would overflow the multiplication
+        d = time.Duration(min(seconds, int(maxRetryAfter/time.Second))) * time.Second
+    } else if t, err := http.ParseTime(value); err == nil {
+        d = t.Sub(now)
+    }
+    return min(max(d, 0), maxRetryAfter)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+    "testing"
+    "time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+    now := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+    tests := []struct {
+        name  string
+        value string
+        want  time.Duration
+    }{
+        {"empty", "", 0},
+        {"seconds", "120", 2 * time.Minute},
+        {"padded seconds", " 30 ", 30 * time.Second},
+        {"negative seconds", "-5", 0},
+        {"seconds over the cap", "10000000000", maxRetryAfter},
+        {"seconds that would overflow", "9223372036854775807", maxRetryAfter},
+        {"HTTP date", "Tue, 02 Jan 2024 15:10:00 GMT", 10 * time.Minute},
+        {"HTTP date in the past", "Tue, 02 Jan 2024 14:00:00 GMT", 0},
+        {"HTTP date past the cap", "Tue, 02 Jan 2025 15:00:00 GMT", maxRetryAfter},
+        {"garbage", "soon", 0},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := parseRetryAfter(tt.value, now); got != tt.want {
+                t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+            }
+        })
+    }
+}
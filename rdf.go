@@ -0,0 +1,55 @@
+package main
+
+import (
+    "encoding/xml"
+)
+
+const rdfNamespace = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+
+// RDFFeed is an RSS 1.0 document. Unlike RSS 2.0 the items are siblings of
+// the channel rather than children of it, and dates and authors come from
+// the Dublin Core module.
+type RDFFeed struct {
+    XMLName xml.Name `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# RDF"`
+    Channel struct {
+        Title       string `xml:"title"`
+        Link        string `xml:"link"`
+        Description string `xml:"description"`
+        // RSS 1.0 feeds commonly carry the syndication module
+        UpdatePeriod    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+        UpdateFrequency string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+    } `xml:"channel"`
+    Item []RDFItem `xml:"item"`
+}
+
+type RDFItem struct {
+    About       string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+    Title       string `xml:"title"`
+    Link        string `xml:"link"`
+    Description string `xml:"description"`
+    Date        string `xml:"http://purl.org/dc/elements/1.1/ date"`
+    Creator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+}
+
+// toRSS maps the RDF feed onto the RSS item model stored by scrapeFeed.
+func (f *RDFFeed) toRSS() *RSSFeed {
+    var feed RSSFeed
+    feed.Channel.Title = f.Channel.Title
+    feed.Channel.Link = f.Channel.Link
+    feed.Channel.Description = f.Channel.Description
+    feed.Channel.UpdatePeriod = f.Channel.UpdatePeriod
+    feed.Channel.UpdateFrequency = f.Channel.UpdateFrequency
+
+    for _, entry := range f.Item {
+        feed.Channel.Item = append(feed.Channel.Item, RSSItem{
+            Title:       entry.Title,
+            Link:        entry.Link,
+            Description: entry.Description,
+            PubDate:     entry.Date,
+            Creator:     entry.Creator,
+            GUID:        entry.About,
+        })
+    }
+
+    return &feed
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "log"
+
+    "github.com/DanielJacob1998/gator/internal/database"
+)
+
+// redirectSightings is how many fetches in a row have to be permanently
+// redirected to the same place before we believe the feed has moved.
+const redirectSightings = 3
+
+// recordRedirect keeps track of where the feed is being permanently
+// redirected to, and moves the feed there once that has been consistent.
+func recordRedirect(ctx context.Context, s *state, feed database.Feed, target string) {
+    if target == "" || target == feed.Url {
+        if feed.RedirectUrl.Valid {
+            if err := s.db.ClearFeedRedirect(ctx, feed.ID); err != nil {
+                log.Printf("Couldn't clear redirect of feed %s: %v", feed.Name, err)
+            }
+        }
+        return
+    }
+
+    sightings, err := s.db.RecordFeedRedirect(ctx, database.RecordFeedRedirectParams{
+        ID: feed.ID,
+        RedirectUrl: sql.NullString{
+            String: target,
+            Valid:  true,
+        },
+    })
+    if err != nil {
+        log.Printf("Couldn't record redirect of feed %s: %v", feed.Name, err)
+        return
+    }
+    if sightings < redirectSightings {
+        log.Printf("Feed %s permanently redirects to %s (%d of %d)", feed.Name, target, sightings, redirectSightings)
+        return
+    }
+
+    if err := moveFeed(ctx, s, feed, target); err != nil {
+        log.Printf("Couldn't move feed %s to %s: %v", feed.Name, target, err)
+        return
+    }
+    log.Printf("Feed %s moved to %s", feed.Name, target)
+}
+
+// moveFeed points the feed at its new URL. If we already have a feed at that
+// URL, the two are merged: follows and posts the other feed doesn't have
+// yet are moved over and this one is deleted.
+func moveFeed(ctx context.Context, s *state, feed database.Feed, target string) error {
+    tx, err := s.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+    q := s.db.WithTx(tx)
+
+    existing, err := q.GetFeedByURL(ctx, target)
+    if errors.Is(err, sql.ErrNoRows) {
+        err = q.UpdateFeedURL(ctx, database.UpdateFeedURLParams{
+            ID:  feed.ID,
+            Url: target,
+        })
+        if err != nil {
+            return fmt.Errorf("couldn't update feed URL: %w", err)
+        }
+        return tx.Commit()
+    }
+    if err != nil {
+        return fmt.Errorf("couldn't look up feed at new URL: %w", err)
+    }
+
+    err = q.MoveFeedFollows(ctx, database.MoveFeedFollowsParams{
+        ToFeedID:   existing.ID,
+        FromFeedID: feed.ID,
+    })
+    if err != nil {
+        return fmt.Errorf("couldn't move feed follows: %w", err)
+    }
+    err = q.MovePosts(ctx, database.MovePostsParams{
+        ToFeedID:   existing.ID,
+        FromFeedID: feed.ID,
+    })
+    if err != nil {
+        return fmt.Errorf("couldn't move posts: %w", err)
+    }
+    err = q.MoveFetchLog(ctx, database.MoveFetchLogParams{
+        ToFeedID:   existing.ID,
+        FromFeedID: feed.ID,
+    })
+    if err != nil {
+        return fmt.Errorf("couldn't move fetch log: %w", err)
+    }
+    // Whatever is left is a duplicate of what the other feed already has.
+    // Downloads of it go with it, so their files have to go too.
+    paths, err := q.GetDownloadPathsForFeed(ctx, feed.ID)
+    if err != nil {
+        return fmt.Errorf("couldn't get downloads of old feed: %w", err)
+    }
+    if err := q.DeleteFeed(ctx, feed.ID); err != nil {
+        return fmt.Errorf("couldn't delete old feed: %w", err)
+    }
+    if err := tx.Commit(); err != nil {
+        return err
+    }
+
+    for _, path := range paths {
+        if err := removeDownloadFiles(path.String); err != nil {
+            log.Printf("Couldn't clean up after feed %s: %v", feed.Name, err)
+        }
+    }
+    return nil
+}
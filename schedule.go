@@ -0,0 +1,204 @@
+package main
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "log"
+    "math"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/DanielJacob1998/gator/internal/database"
+)
+
+const (
+    // Bounds on the interval worked out from a feed's posting cadence
+    minAdaptiveInterval = 15 * time.Minute
+    maxAdaptiveInterval = 24 * time.Hour
+    // defaultFetchInterval is used until a feed has enough posts to judge
+    // how often it publishes.
+    defaultFetchInterval = time.Hour
+    // cadenceSamples is how many recent posts the cadence is worked out from.
+    cadenceSamples = 10
+)
+
+var syndicationPeriods = map[string]time.Duration{
+    "hourly":  time.Hour,
+    "daily":   24 * time.Hour,
+    "weekly":  7 * 24 * time.Hour,
+    "monthly": 30 * 24 * time.Hour,
+    "yearly":  365 * 24 * time.Hour,
+}
+
+// scheduleNextFetch works out when the feed is next due and stores it.
+// feedData is nil when the server answered that nothing changed.
+// The interval worked out from the feed is kept apart from next_fetch_at,
+// which failure backoff and Retry-After push out as well, so a 304 can pick
+// it up again.
+func scheduleNextFetch(ctx context.Context, s *state, feed database.Feed, feedData *RSSFeed) {
+    now := time.Now().UTC()
+    interval := fetchDelay(ctx, s, feed, feedData, now)
+    delay := interval
+    if feedData != nil {
+        delay = skipDelay(feedData, now, delay)
+    }
+
+    adaptive := feed.AdaptiveIntervalSeconds
+    if !feed.FetchIntervalSeconds.Valid {
+        adaptive = sql.NullInt32{
+            Int32: int32(min(interval.Seconds(), math.MaxInt32)),
+            Valid: true,
+        }
+    }
+
+    err := s.db.ScheduleFeedFetch(ctx, database.ScheduleFeedFetchParams{
+        ID:                      feed.ID,
+        DelaySeconds:            delay.Seconds(),
+        AdaptiveIntervalSeconds: adaptive,
+    })
+    if err != nil {
+        log.Printf("Couldn't schedule next fetch of feed %s: %v", feed.Name, err)
+    }
+}
+
+// fetchDelay is how long to wait before fetching the feed again. An interval
+// set with "feed set-interval" wins; otherwise we go by how often the feed
+// has been publishing, but never poll faster than the feed itself asks.
+func fetchDelay(ctx context.Context, s *state, feed database.Feed, feedData *RSSFeed, now time.Time) time.Duration {
+    if feed.FetchIntervalSeconds.Valid {
+        return fetchInterval(feed.FetchIntervalSeconds)
+    }
+
+    if feedData == nil {
+        // Nothing new to go on, keep the interval we settled on last time
+        if feed.AdaptiveIntervalSeconds.Valid && feed.AdaptiveIntervalSeconds.Int32 > 0 {
+            return fetchInterval(feed.AdaptiveIntervalSeconds)
+        }
+        return postingCadence(ctx, s, feed, now)
+    }
+
+    delay := postingCadence(ctx, s, feed, now)
+    if minutes, err := strconv.Atoi(strings.TrimSpace(feedData.Channel.TTL)); err == nil && minutes > 0 {
+        delay = max(delay, time.Duration(minutes)*time.Minute)
+    }
+    if period, ok := syndicationPeriods[strings.ToLower(strings.TrimSpace(feedData.Channel.UpdatePeriod))]; ok {
+        frequency, err := strconv.Atoi(strings.TrimSpace(feedData.Channel.UpdateFrequency))
+        if err != nil || frequency < 1 {
+            frequency = 1
+        }
+        delay = max(delay, period/time.Duration(frequency))
+    }
+    return delay
+}
+
+// postingCadence polls at half the feed's typical gap between posts, so new
+// posts are picked up reasonably soon. A feed that has gone quiet for longer
+// than its usual gap is polled less and less often.
+func postingCadence(ctx context.Context, s *state, feed database.Feed, now time.Time) time.Duration {
+    times, err := s.db.GetRecentPublishTimes(ctx, database.GetRecentPublishTimesParams{
+        FeedID: feed.ID,
+        Limit:  cadenceSamples,
+    })
+    if err != nil {
+        log.Printf("Couldn't get recent posts of feed %s: %v", feed.Name, err)
+        return defaultFetchInterval
+    }
+    if len(times) < 2 {
+        return defaultFetchInterval
+    }
+
+    newest, oldest := times[0].Time, times[len(times)-1].Time
+    gap := newest.Sub(oldest) / time.Duration(len(times)-1)
+    gap = max(gap, now.Sub(newest))
+    return min(max(gap/2, minAdaptiveInterval), maxAdaptiveInterval)
+}
+
+// skipDelay stretches delay so the fetch doesn't land in the hours (GMT) or
+// days the feed's skipHours and skipDays ask us to leave it alone.
+func skipDelay(feedData *RSSFeed, now time.Time, delay time.Duration) time.Duration {
+    skipHours := map[int]bool{}
+    for _, hour := range feedData.Channel.SkipHours {
+        if n, err := strconv.Atoi(strings.TrimSpace(hour)); err == nil {
+            // Some feeds count hours 1 to 24
+            skipHours[n%24] = true
+        }
+    }
+    skipDays := map[string]bool{}
+    for _, day := range feedData.Channel.SkipDays {
+        skipDays[strings.ToLower(strings.TrimSpace(day))] = true
+    }
+    if len(skipHours) == 0 && len(skipDays) == 0 {
+        return delay
+    }
+
+    next := now.Add(delay)
+    // A week covers every hour and day there is to skip
+    for i := 0; i < 7*24; i++ {
+        if !skipHours[next.Hour()] && !skipDays[strings.ToLower(next.Weekday().String())] {
+            break
+        }
+        next = next.Truncate(time.Hour).Add(time.Hour)
+    }
+    return next.Sub(now)
+}
+
+// parseFetchInterval accepts anything time.ParseDuration does, plus whole
+// days written as "7d" since hour counts get unwieldy for slow feeds.
+func parseFetchInterval(value string) (time.Duration, error) {
+    if days, ok := strings.CutSuffix(value, "d"); ok {
+        n, err := strconv.Atoi(days)
+        if err != nil {
+            return 0, fmt.Errorf("invalid number of days: %s", days)
+        }
+        return time.Duration(n) * 24 * time.Hour, nil
+    }
+    return time.ParseDuration(value)
+}
+
+func handlerSetFeedInterval(s *state, cmd command) error {
+    if len(cmd.Args) != 2 {
+        return fmt.Errorf("usage: %s <url> <interval|default>", cmd.Name)
+    }
+
+    feed, err := s.db.GetFeedByURL(context.Background(), cmd.Args[0])
+    if err != nil {
+        return fmt.Errorf("couldn't find feed: %w", err)
+    }
+
+    // "default" goes back to scheduling by the feed's posting cadence
+    var seconds sql.NullInt32
+    if cmd.Args[1] != "default" {
+        interval, err := parseFetchInterval(cmd.Args[1])
+        if err != nil {
+            return fmt.Errorf("invalid interval: %w", err)
+        }
+        if interval < time.Minute || interval.Seconds() > math.MaxInt32 {
+            return fmt.Errorf("interval must be between 1m and %dd", math.MaxInt32/(24*60*60))
+        }
+        seconds = sql.NullInt32{
+            Int32: int32(interval.Seconds()),
+            Valid: true,
+        }
+    }
+
+    err = s.db.SetFeedFetchInterval(context.Background(), database.SetFeedFetchIntervalParams{
+        ID:                   feed.ID,
+        FetchIntervalSeconds: seconds,
+    })
+    if err != nil {
+        return fmt.Errorf("couldn't set fetch interval: %w", err)
+    }
+
+    if seconds.Valid {
+        fmt.Printf("Feed %s will be fetched at most every %s\n", feed.Name, fetchInterval(seconds))
+    } else {
+        fmt.Printf("Feed %s will be fetched as often as it publishes\n", feed.Name)
+    }
+    return nil
+}
+
+func fetchInterval(seconds sql.NullInt32) time.Duration {
+    return time.Duration(seconds.Int32) * time.Second
+}
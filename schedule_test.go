@@ -0,0 +1,49 @@
+package main
+
+import (
+    "strconv"
+    "testing"
+    "time"
+)
+
+func TestSkipDelay(t *testing.T) {
+    // A Monday
+    now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+    tests := []struct {
+        name      string
+        skipHours []string
+        skipDays  []string
+        delay     time.Duration
+        want      time.Duration
+    }{
+        {"nothing to skip", nil, nil, time.Hour, time.Hour},
+        {"hour not skipped", []string{"3"}, nil, time.Hour, time.Hour},
+        {"skipped hour", []string{"11"}, nil, time.Hour, 2 * time.Hour},
+        {"skipped hours in a row", []string{"11", "12", "13"}, nil, 90 * time.Minute, 4 * time.Hour},
+        {"hour 24 is midnight", []string{"24"}, nil, 14 * time.Hour, 15 * time.Hour},
+        {"skipped day", nil, []string{"Tuesday"}, 24 * time.Hour, 38 * time.Hour},
+        {"days are case insensitive", nil, []string{" tuesday "}, 24 * time.Hour, 38 * time.Hour},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var feed RSSFeed
+            feed.Channel.SkipHours = tt.skipHours
+            feed.Channel.SkipDays = tt.skipDays
+            if got := skipDelay(&feed, now, tt.delay); got != tt.want {
+                t.Errorf("skipDelay = %s, want %s", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestSkipDelayEverythingSkipped(t *testing.T) {
+    var feed RSSFeed
+    for hour := 0; hour < 24; hour++ {
+        feed.Channel.SkipHours = append(feed.Channel.SkipHours, strconv.Itoa(hour))
+    }
+    now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+    if got := skipDelay(&feed, now, time.Hour); got > 8*24*time.Hour {
+        t.Errorf("skipDelay = %s, want at most a week past the delay", got)
+    }
+}
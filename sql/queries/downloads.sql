@@ -0,0 +1,86 @@
+-- name: QueueDownload :exec
+INSERT INTO downloads (id, created_at, updated_at, user_id, enclosure_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, enclosure_id) DO NOTHING;
+
+-- name: QueueEnclosureForFollowers :exec
+INSERT INTO downloads (id, created_at, updated_at, user_id, enclosure_id)
+SELECT gen_random_uuid(), NOW(), NOW(), feed_follows.user_id, enclosures.id
+FROM enclosures
+JOIN posts ON posts.id = enclosures.post_id
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE enclosures.id = $1
+ON CONFLICT (user_id, enclosure_id) DO NOTHING;
+
+-- name: GetLatestEnclosuresForUser :many
+SELECT enclosures.id FROM enclosures
+JOIN posts ON posts.id = enclosures.post_id
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $1
+AND posts.id IN (
+    SELECT latest.id FROM posts latest
+    WHERE latest.feed_id = posts.feed_id
+    AND EXISTS (SELECT 1 FROM enclosures episode WHERE episode.post_id = latest.id)
+    ORDER BY latest.published_at DESC NULLS LAST
+    LIMIT $2
+);
+
+-- name: GetPendingDownloadsForUser :many
+SELECT downloads.*, enclosures.url AS enclosure_url, enclosures.size_bytes, posts.title AS post_title, feeds.name AS feed_name
+FROM downloads
+JOIN enclosures ON enclosures.id = downloads.enclosure_id
+JOIN posts ON posts.id = enclosures.post_id
+JOIN feeds ON feeds.id = posts.feed_id
+WHERE downloads.user_id = $1
+AND downloads.status <> 'done'
+ORDER BY posts.published_at DESC;
+
+-- name: GetDownloadedBytesForUser :one
+SELECT COALESCE(SUM(bytes), 0)::BIGINT FROM downloads
+WHERE user_id = $1 AND status = 'done';
+
+-- name: CompleteDownload :exec
+UPDATE downloads
+SET status = 'done',
+path = $2,
+bytes = $3,
+error = NULL,
+completed_at = NOW(),
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: FailDownload :exec
+UPDATE downloads
+SET status = 'failed',
+error = $2,
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetExpiredDownloadsForUser :many
+SELECT downloads.* FROM downloads
+JOIN enclosures ON enclosures.id = downloads.enclosure_id
+JOIN posts ON posts.id = enclosures.post_id
+WHERE downloads.user_id = $1
+AND posts.id NOT IN (
+    SELECT latest.id FROM posts latest
+    WHERE latest.feed_id = posts.feed_id
+    AND EXISTS (SELECT 1 FROM enclosures episode WHERE episode.post_id = latest.id)
+    ORDER BY latest.published_at DESC NULLS LAST
+    LIMIT $2
+);
+
+-- name: DeleteDownload :exec
+DELETE FROM downloads WHERE id = $1;
+
+-- name: SetDownloadPath :exec
+UPDATE downloads
+SET path = $2,
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetDownloadPathsForFeed :many
+SELECT downloads.path FROM downloads
+JOIN enclosures ON enclosures.id = downloads.enclosure_id
+JOIN posts ON posts.id = enclosures.post_id
+WHERE posts.feed_id = $1
+AND downloads.path IS NOT NULL;
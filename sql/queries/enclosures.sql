@@ -0,0 +1,10 @@
+-- name: CreateEnclosure :one
+INSERT INTO enclosures (id, created_at, updated_at, post_id, url, mime_type, size_bytes, duration_seconds)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (post_id, url) DO NOTHING
+RETURNING *;
+
+-- name: GetEnclosuresForPost :many
+SELECT * FROM enclosures
+WHERE post_id = $1
+ORDER BY created_at ASC;
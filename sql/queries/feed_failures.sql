@@ -0,0 +1,32 @@
+-- name: RecordFeedSuccess :exec
+UPDATE feeds
+SET consecutive_failures = 0,
+last_error = NULL,
+last_success_at = NOW()
+WHERE id = $1;
+
+-- name: RecordFeedFailure :one
+UPDATE feeds
+SET consecutive_failures = consecutive_failures + 1,
+last_error = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: DisableFeed :exec
+UPDATE feeds
+SET disabled_at = NOW(),
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: ReenableFeed :exec
+UPDATE feeds
+SET disabled_at = NULL,
+consecutive_failures = 0,
+next_fetch_at = NULL,
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetBrokenFeeds :many
+SELECT * FROM feeds
+WHERE consecutive_failures > 0 OR disabled_at IS NOT NULL
+ORDER BY disabled_at DESC NULLS LAST, consecutive_failures DESC;
@@ -1,7 +1,7 @@
 -- name: CreateFeedFollow :one
 WITH inserted_feed_follow AS (
-    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
-    VALUES ($1, $2, $3, $4, $5)
+    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, category)
+    VALUES ($1, $2, $3, $4, $5, $6)
     RETURNING *
 )
 SELECT
@@ -16,6 +16,7 @@ INNER JOIN feeds ON inserted_feed_follow.feed_id = feeds.id;
 SELECT
     ff.*,
     feeds.name as feed_name,
+    feeds.url as feed_url,
     users.name as user_name
 FROM feed_follows ff
 INNER JOIN feeds ON feeds.id = ff.feed_id
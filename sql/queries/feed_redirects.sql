@@ -0,0 +1,41 @@
+-- name: RecordFeedRedirect :one
+UPDATE feeds
+SET redirect_sightings = CASE WHEN redirect_url = $2 THEN redirect_sightings + 1 ELSE 1 END,
+redirect_url = $2
+WHERE id = $1
+RETURNING redirect_sightings;
+
+-- name: ClearFeedRedirect :exec
+UPDATE feeds
+SET redirect_url = NULL,
+redirect_sightings = 0
+WHERE id = $1 AND redirect_url IS NOT NULL;
+
+-- name: UpdateFeedURL :exec
+UPDATE feeds
+SET url = $2,
+redirect_url = NULL,
+redirect_sightings = 0,
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: MoveFeedFollows :exec
+UPDATE feed_follows
+SET feed_id = sqlc.arg(to_feed_id),
+updated_at = NOW()
+WHERE feed_id = sqlc.arg(from_feed_id)
+AND user_id NOT IN (SELECT user_id FROM feed_follows WHERE feed_id = sqlc.arg(to_feed_id));
+
+-- name: MovePosts :exec
+UPDATE posts
+SET feed_id = sqlc.arg(to_feed_id)
+WHERE feed_id = sqlc.arg(from_feed_id)
+AND guid NOT IN (SELECT guid FROM posts WHERE feed_id = sqlc.arg(to_feed_id));
+
+-- name: MoveFetchLog :exec
+UPDATE fetch_log
+SET feed_id = sqlc.arg(to_feed_id)
+WHERE feed_id = sqlc.arg(from_feed_id);
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds WHERE id = $1;
@@ -11,9 +11,19 @@ VALUES (
 RETURNING *;
 
 -- name: GetAllFeeds :many
-SELECT feeds.name, feeds.url, users.name as creator_name
+SELECT feeds.name, feeds.url, feeds.fetch_interval_seconds, feeds.next_fetch_at, users.name as creator_name
 FROM feeds
 JOIN users ON feeds.user_id = users.id;
 
 -- name: GetFeedByURL :one
 SELECT * FROM feeds WHERE url = $1;
+
+-- name: GetFeedByID :one
+SELECT * FROM feeds WHERE id = $1;
+
+-- name: SetFeedFetchInterval :exec
+UPDATE feeds
+SET fetch_interval_seconds = $2,
+next_fetch_at = last_fetched_at + make_interval(secs => $2::integer),
+updated_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,19 @@
+-- name: CreateFetchLog :exec
+INSERT INTO fetch_log (id, feed_id, started_at, finished_at, status_code, bytes, items_seen, posts_inserted, error)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- name: GetFeedHealth :many
+SELECT
+    feeds.id,
+    feeds.name,
+    feeds.url,
+    feeds.last_success_at,
+    feeds.disabled_at,
+    COUNT(fetch_log.id) AS fetches,
+    COUNT(fetch_log.id) FILTER (WHERE fetch_log.error IS NULL) AS successes,
+    COALESCE(AVG(EXTRACT(EPOCH FROM fetch_log.finished_at - fetch_log.started_at)), 0)::float8 AS avg_seconds,
+    COALESCE(MAX(EXTRACT(EPOCH FROM fetch_log.finished_at - fetch_log.started_at)), 0)::float8 AS max_seconds
+FROM feeds
+LEFT JOIN fetch_log ON fetch_log.feed_id = feeds.id AND fetch_log.started_at >= $1
+GROUP BY feeds.id
+ORDER BY feeds.name;
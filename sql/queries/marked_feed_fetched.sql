@@ -9,3 +9,45 @@ RETURNING *;
 SELECT * FROM feeds
 ORDER BY last_fetched_at ASC NULLS FIRST
 LIMIT 1;
+
+-- name: UpdateFeedValidators :exec
+UPDATE feeds
+SET etag = $2,
+last_modified = $3
+WHERE id = $1;
+
+-- name: ClaimFeedsToFetch :many
+UPDATE feeds
+SET claimed_until = NOW() + make_interval(secs => sqlc.arg(lease_seconds))
+WHERE id IN (
+    SELECT id FROM feeds
+    WHERE disabled_at IS NULL
+    AND (claimed_until IS NULL OR claimed_until < NOW())
+    AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
+    ORDER BY last_fetched_at ASC NULLS FIRST
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ReleaseFeed :exec
+UPDATE feeds
+SET claimed_until = NULL
+WHERE id = $1;
+
+-- name: SetFeedNextFetch :exec
+UPDATE feeds
+SET next_fetch_at = NOW() + make_interval(secs => sqlc.arg(delay_seconds))
+WHERE id = sqlc.arg(id);
+
+-- name: ResetFeedFetched :exec
+UPDATE feeds
+SET last_fetched_at = sqlc.arg(last_fetched_at),
+next_fetch_at = NULL
+WHERE id = sqlc.arg(id);
+
+-- name: ScheduleFeedFetch :exec
+UPDATE feeds
+SET next_fetch_at = NOW() + make_interval(secs => sqlc.arg(delay_seconds)),
+adaptive_interval_seconds = sqlc.arg(adaptive_interval_seconds)
+WHERE id = sqlc.arg(id);
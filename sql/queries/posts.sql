@@ -1,6 +1,16 @@
--- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+-- name: UpsertPost :one
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, author, content, guid, content_hash)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = EXCLUDED.title,
+url = EXCLUDED.url,
+description = EXCLUDED.description,
+author = EXCLUDED.author,
+content = EXCLUDED.content,
+content_hash = EXCLUDED.content_hash,
+updated_at = EXCLUDED.updated_at,
+revised_at = EXCLUDED.updated_at
+WHERE posts.content_hash <> EXCLUDED.content_hash
 RETURNING *;
 --
 
@@ -12,3 +22,27 @@ WHERE feed_follows.user_id = $1
 ORDER BY posts.published_at DESC
 LIMIT $2;
 --
+
+-- name: CreatePostRevision :exec
+INSERT INTO post_revisions (id, created_at, post_id, title, url, description, content, content_hash)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+--
+
+-- name: GetRecentPublishTimes :many
+SELECT published_at FROM posts
+WHERE feed_id = $1 AND published_at IS NOT NULL
+ORDER BY published_at DESC
+LIMIT $2;
+--
+
+-- name: AdoptLegacyPost :exec
+UPDATE posts
+SET guid = sqlc.arg(guid)
+WHERE feed_id = sqlc.arg(feed_id)
+AND url = sqlc.arg(url)
+AND guid = url
+AND NOT EXISTS (
+    SELECT 1 FROM posts adopted
+    WHERE adopted.feed_id = sqlc.arg(feed_id)
+    AND adopted.guid = sqlc.arg(guid)
+);
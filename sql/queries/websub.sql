@@ -0,0 +1,27 @@
+-- name: UpsertWebSubSubscription :one
+INSERT INTO websub_subscriptions (id, created_at, updated_at, feed_id, hub_url, topic_url, secret)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (feed_id) DO UPDATE
+SET hub_url = EXCLUDED.hub_url,
+topic_url = EXCLUDED.topic_url,
+secret = EXCLUDED.secret,
+updated_at = EXCLUDED.updated_at
+RETURNING *;
+
+-- name: GetWebSubSubscriptionByFeed :one
+SELECT * FROM websub_subscriptions WHERE feed_id = $1;
+
+-- name: ActivateWebSubSubscription :exec
+UPDATE websub_subscriptions
+SET status = 'active',
+lease_expires_at = $2,
+updated_at = NOW()
+WHERE feed_id = $1;
+
+-- name: DeleteWebSubSubscription :exec
+DELETE FROM websub_subscriptions WHERE feed_id = $1;
+
+-- name: GetExpiringWebSubSubscriptions :many
+SELECT * FROM websub_subscriptions
+WHERE status = 'active'
+AND lease_expires_at < $1;
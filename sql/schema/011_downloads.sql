@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE downloads (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    enclosure_id UUID NOT NULL REFERENCES enclosures(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'queued',
+    path TEXT,
+    bytes BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    completed_at TIMESTAMP,
+    UNIQUE(user_id, enclosure_id)
+);
+
+-- +goose Down
+DROP TABLE downloads;
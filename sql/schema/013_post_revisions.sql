@@ -0,0 +1,23 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN content_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE posts ADD COLUMN revised_at TIMESTAMP;
+UPDATE posts SET content_hash = md5(title || E'\n' || COALESCE(description, '') || E'\n' || COALESCE(content, ''));
+
+CREATE TABLE post_revisions (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    url TEXT NOT NULL,
+    description TEXT,
+    content TEXT,
+    content_hash TEXT NOT NULL
+);
+
+INSERT INTO post_revisions (id, created_at, post_id, title, url, description, content, content_hash)
+SELECT gen_random_uuid(), updated_at, id, title, url, description, content, content_hash FROM posts;
+
+-- +goose Down
+DROP TABLE post_revisions;
+ALTER TABLE posts DROP COLUMN revised_at;
+ALTER TABLE posts DROP COLUMN content_hash;
@@ -0,0 +1,6 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN next_fetch_at TIMESTAMP;
+UPDATE feeds SET next_fetch_at = last_fetched_at + make_interval(secs => fetch_interval_seconds);
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN next_fetch_at;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE fetch_log (
+    id UUID PRIMARY KEY,
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL,
+    status_code INTEGER,
+    bytes INTEGER,
+    items_seen INTEGER NOT NULL DEFAULT 0,
+    posts_inserted INTEGER NOT NULL DEFAULT 0,
+    error TEXT
+);
+
+CREATE INDEX fetch_log_feed_id_started_at_idx ON fetch_log (feed_id, started_at);
+
+-- +goose Down
+DROP TABLE fetch_log;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN redirect_url TEXT;
+ALTER TABLE feeds ADD COLUMN redirect_sightings INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN redirect_sightings;
+ALTER TABLE feeds DROP COLUMN redirect_url;
@@ -0,0 +1,342 @@
+package main
+
+import (
+    "context"
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/sha1"
+    "crypto/sha256"
+    "crypto/sha512"
+    "database/sql"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "hash"
+    "io"
+    "log"
+    "net"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/DanielJacob1998/gator/internal/config"
+    "github.com/DanielJacob1998/gator/internal/database"
+)
+
+const (
+    // webSubLeaseSeconds is the lease we ask hubs for; they may grant less.
+    webSubLeaseSeconds = 10 * 24 * 60 * 60
+    // webSubRenewBefore is how long before expiry a lease is renewed.
+    webSubRenewBefore = 48 * time.Hour
+    // webSubRetryAfter is how long a request the hub hasn't verified yet
+    // is left before asking again.
+    webSubRetryAfter = 6 * time.Hour
+    // webSubMaxBody caps the size of pushed content we accept.
+    webSubMaxBody = 10 << 20
+    // webSubShutdownTimeout is how long shutdown waits for pushes in
+    // progress.
+    webSubShutdownTimeout = 10 * time.Second
+)
+
+var webSubSignatureHashes = map[string]func() hash.Hash{
+    "sha1":   sha1.New,
+    "sha256": sha256.New,
+    "sha384": sha512.New384,
+    "sha512": sha512.New,
+}
+
+// subscribeIfNeeded subscribes to the feed's hub unless we already hold a
+// subscription, or asked for one recently and are waiting on the hub.
+func subscribeIfNeeded(s *state, feed database.Feed, hub string, topic string) {
+    if !webSubActive(s) {
+        return
+    }
+    // We'd have to send our secret in the clear, so stick to polling
+    if !isHTTPS(hub) {
+        return
+    }
+    if topic == "" {
+        topic = feed.Url
+    }
+
+    sub, err := s.db.GetWebSubSubscriptionByFeed(context.Background(), feed.ID)
+    if err == nil {
+        if sub.Status == "active" || time.Since(sub.UpdatedAt) < webSubRetryAfter {
+            return
+        }
+    } else if !errors.Is(err, sql.ErrNoRows) {
+        log.Printf("Couldn't get WebSub subscription for feed %s: %v", feed.Name, err)
+        return
+    }
+
+    if err := subscribeWebSub(s, feed, hub, topic, ""); err != nil {
+        log.Printf("Couldn't subscribe to feed %s through %s: %v", feed.Name, hub, err)
+        return
+    }
+    log.Printf("Requested WebSub subscription for feed %s from %s", feed.Name, hub)
+}
+
+// subscribeWebSub sends a subscription request to the hub. An empty secret
+// generates a new one; renewals pass the existing secret so content pushed
+// in the meantime still verifies.
+func subscribeWebSub(s *state, feed database.Feed, hub string, topic string, secret string) error {
+    if !isHTTPS(hub) {
+        return fmt.Errorf("hub %s doesn't use https", hub)
+    }
+    if secret == "" {
+        buf := make([]byte, 32)
+        if _, err := rand.Read(buf); err != nil {
+            return err
+        }
+        secret = hex.EncodeToString(buf)
+    }
+
+    _, err := s.db.UpsertWebSubSubscription(context.Background(), database.UpsertWebSubSubscriptionParams{
+        ID:        uuid.New(),
+        CreatedAt: time.Now().UTC(),
+        UpdatedAt: time.Now().UTC(),
+        FeedID:    feed.ID,
+        HubUrl:    hub,
+        TopicUrl:  topic,
+        Secret:    secret,
+    })
+    if err != nil {
+        return fmt.Errorf("couldn't store subscription: %w", err)
+    }
+
+    form := url.Values{}
+    form.Set("hub.callback", webSubCallbackURL(s, feed.ID))
+    form.Set("hub.mode", "subscribe")
+    form.Set("hub.topic", topic)
+    form.Set("hub.secret", secret)
+    form.Set("hub.lease_seconds", strconv.Itoa(webSubLeaseSeconds))
+
+    req, err := s.client.newRequest(context.Background(), "POST", hub, strings.NewReader(form.Encode()))
+    if err != nil {
+        return err
+    }
+    req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+    resp, err := s.client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode < 200 || resp.StatusCode > 299 {
+        return fmt.Errorf("hub answered %s", resp.Status)
+    }
+    return nil
+}
+
+// renewWebSubLeases resubscribes to hubs whose leases are about to run out.
+func renewWebSubLeases(s *state) {
+    if !webSubActive(s) {
+        return
+    }
+
+    subs, err := s.db.GetExpiringWebSubSubscriptions(context.Background(), sql.NullTime{
+        Time:  time.Now().UTC().Add(webSubRenewBefore),
+        Valid: true,
+    })
+    if err != nil {
+        log.Printf("Couldn't get expiring WebSub subscriptions: %v", err)
+        return
+    }
+
+    for _, sub := range subs {
+        if time.Since(sub.UpdatedAt) < webSubRetryAfter {
+            // Renewal already requested, waiting on the hub
+            continue
+        }
+        feed, err := s.db.GetFeedByID(context.Background(), sub.FeedID)
+        if err != nil {
+            log.Printf("Couldn't get feed for WebSub subscription %s: %v", sub.ID, err)
+            continue
+        }
+        if err := subscribeWebSub(s, feed, sub.HubUrl, sub.TopicUrl, sub.Secret); err != nil {
+            log.Printf("Couldn't renew WebSub subscription for feed %s: %v", feed.Name, err)
+            continue
+        }
+        log.Printf("Renewing WebSub subscription for feed %s", feed.Name)
+    }
+}
+
+// webSubEnabled reports whether the config asks for WebSub: hubs need a
+// public callback URL to reach us on and a listener behind it.
+func webSubEnabled(cfg *config.Config) bool {
+    return cfg.WebSubCallbackURL != "" && cfg.WebSubListenAddr != ""
+}
+
+// webSubActive reports whether subscribing makes sense right now, that is
+// WebSub is enabled and the listener is up to verify our subscriptions.
+func webSubActive(s *state) bool {
+    return webSubEnabled(s.cfg) && s.webSubListening.Load()
+}
+
+func isHTTPS(rawURL string) bool {
+    u, err := url.Parse(rawURL)
+    return err == nil && strings.EqualFold(u.Scheme, "https")
+}
+
+func webSubCallbackURL(s *state, feedID uuid.UUID) string {
+    return strings.TrimSuffix(s.cfg.WebSubCallbackURL, "/") + "/websub/" + feedID.String()
+}
+
+// serveWebSub starts the callback listener hubs use to verify subscriptions
+// and push new content. Subscribing stops if the listener goes down.
+func serveWebSub(s *state) (*http.Server, error) {
+    mux := http.NewServeMux()
+    mux.HandleFunc("GET /websub/{feedID}", func(w http.ResponseWriter, r *http.Request) {
+        handleWebSubVerification(s, w, r)
+    })
+    mux.HandleFunc("POST /websub/{feedID}", func(w http.ResponseWriter, r *http.Request) {
+        handleWebSubContent(s, w, r)
+    })
+
+    listener, err := net.Listen("tcp", s.cfg.WebSubListenAddr)
+    if err != nil {
+        return nil, err
+    }
+    server := &http.Server{Handler: mux}
+
+    s.webSubListening.Store(true)
+    log.Printf("Listening for WebSub callbacks on %s", s.cfg.WebSubListenAddr)
+    go func() {
+        err := server.Serve(listener)
+        s.webSubListening.Store(false)
+        if !errors.Is(err, http.ErrServerClosed) {
+            log.Printf("WebSub listener stopped, no longer subscribing: %v", err)
+        }
+    }()
+    return server, nil
+}
+
+// shutdownWebSub stops the WebSub listener, giving pushes in progress a
+// little while to finish storing their posts.
+func shutdownWebSub(server *http.Server) {
+    ctx, cancel := context.WithTimeout(context.Background(), webSubShutdownTimeout)
+    defer cancel()
+    if err := server.Shutdown(ctx); err != nil {
+        log.Printf("Couldn't shut down WebSub listener cleanly: %v", err)
+    }
+}
+
+func webSubSubscription(s *state, r *http.Request) (database.WebsubSubscription, bool) {
+    feedID, err := uuid.Parse(r.PathValue("feedID"))
+    if err != nil {
+        return database.WebsubSubscription{}, false
+    }
+    sub, err := s.db.GetWebSubSubscriptionByFeed(r.Context(), feedID)
+    if err != nil {
+        return database.WebsubSubscription{}, false
+    }
+    return sub, true
+}
+
+// handleWebSubVerification answers the hub's intent verification by echoing
+// the challenge for subscriptions we actually asked for.
+func handleWebSubVerification(s *state, w http.ResponseWriter, r *http.Request) {
+    sub, ok := webSubSubscription(s, r)
+    query := r.URL.Query()
+    if !ok || query.Get("hub.topic") != sub.TopicUrl {
+        http.NotFound(w, r)
+        return
+    }
+
+    switch query.Get("hub.mode") {
+    case "subscribe":
+        lease, err := strconv.Atoi(query.Get("hub.lease_seconds"))
+        if err != nil || lease <= 0 {
+            lease = webSubLeaseSeconds
+        }
+        err = s.db.ActivateWebSubSubscription(r.Context(), database.ActivateWebSubSubscriptionParams{
+            FeedID: sub.FeedID,
+            LeaseExpiresAt: sql.NullTime{
+                Time:  time.Now().UTC().Add(time.Duration(lease) * time.Second),
+                Valid: true,
+            },
+        })
+        if err != nil {
+            log.Printf("Couldn't activate WebSub subscription %s: %v", sub.ID, err)
+            http.Error(w, "internal error", http.StatusInternalServerError)
+            return
+        }
+        log.Printf("WebSub subscription for %s verified, lease %ds", sub.TopicUrl, lease)
+    case "unsubscribe", "denied":
+        if err := s.db.DeleteWebSubSubscription(r.Context(), sub.FeedID); err != nil {
+            log.Printf("Couldn't delete WebSub subscription %s: %v", sub.ID, err)
+        }
+        log.Printf("WebSub subscription for %s ended (%s)", sub.TopicUrl, query.Get("hub.mode"))
+        if query.Get("hub.mode") == "denied" {
+            w.WriteHeader(http.StatusOK)
+            return
+        }
+    default:
+        http.Error(w, "unknown hub.mode", http.StatusBadRequest)
+        return
+    }
+
+    w.Header().Set("Content-Type", "text/plain")
+    io.WriteString(w, query.Get("hub.challenge"))
+}
+
+// handleWebSubContent ingests content pushed by the hub. Content with a bad
+// signature is acknowledged, as the spec requires, but dropped.
+func handleWebSubContent(s *state, w http.ResponseWriter, r *http.Request) {
+    sub, ok := webSubSubscription(s, r)
+    if !ok {
+        http.NotFound(w, r)
+        return
+    }
+
+    body, err := io.ReadAll(io.LimitReader(r.Body, webSubMaxBody))
+    if err != nil {
+        http.Error(w, "couldn't read body", http.StatusBadRequest)
+        return
+    }
+    w.WriteHeader(http.StatusAccepted)
+
+    if !validWebSubSignature(sub.Secret, r.Header.Get("X-Hub-Signature"), body) {
+        log.Printf("Dropping WebSub content for %s with a bad signature", sub.TopicUrl)
+        return
+    }
+
+    feed, err := s.db.GetFeedByID(r.Context(), sub.FeedID)
+    if err != nil {
+        log.Printf("Couldn't get feed for WebSub subscription %s: %v", sub.ID, err)
+        return
+    }
+    feedData, err := decodeFeed(body, r.Header.Get("Content-Type"))
+    if err != nil {
+        log.Printf("Couldn't parse WebSub content for feed %s: %v", feed.Name, err)
+        return
+    }
+
+    stored := storeItems(r.Context(), s, feed, feedData.Channel.Item, time.Now().UTC())
+    log.Printf("Feed %s pushed, %v posts received, %v new, %v updated", feed.Name, len(feedData.Channel.Item), stored.Inserted, stored.Updated)
+}
+
+// validWebSubSignature checks an X-Hub-Signature header of the form
+// method=hexdigest against an HMAC of the body keyed with our secret.
+func validWebSubSignature(secret string, header string, body []byte) bool {
+    method, signature, ok := strings.Cut(header, "=")
+    if !ok {
+        return false
+    }
+    newHash, ok := webSubSignatureHashes[strings.ToLower(method)]
+    if !ok {
+        return false
+    }
+    want, err := hex.DecodeString(signature)
+    if err != nil {
+        return false
+    }
+
+    mac := hmac.New(newHash, []byte(secret))
+    mac.Write(body)
+    return hmac.Equal(mac.Sum(nil), want)
+}
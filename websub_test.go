@@ -0,0 +1,43 @@
+package main
+
+import (
+    "crypto/hmac"
+    "crypto/sha1"
+    "crypto/sha256"
+    "encoding/hex"
+    "hash"
+    "testing"
+)
+
+func TestValidWebSubSignature(t *testing.T) {
+    body := []byte(`<feed xmlns="http://www.w3.org/2005/Atom"></feed>`)
+    sign := func(newHash func() hash.Hash, secret string) string {
+        mac := hmac.New(newHash, []byte(secret))
+        mac.Write(body)
+        return hex.EncodeToString(mac.Sum(nil))
+    }
+
+    tests := []struct {
+        name   string
+        header string
+        want   bool
+    }{
+        {"sha1", "sha1=" + sign(sha1.New, "secret"), true},
+        {"sha256", "sha256=" + sign(sha256.New, "secret"), true},
+        {"method is case insensitive", "SHA256=" + sign(sha256.New, "secret"), true},
+        {"wrong secret", "sha1=" + sign(sha1.New, "other"), false},
+        {"method doesn't match digest", "sha256=" + sign(sha1.New, "secret"), false},
+        {"unknown method", "md5=" + sign(sha1.New, "secret"), false},
+        {"not hex", "sha1=zz", false},
+        {"no method", sign(sha1.New, "secret"), false},
+        {"missing", "", false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := validWebSubSignature("secret", tt.header, body); got != tt.want {
+                t.Errorf("validWebSubSignature(%q) = %v, want %v", tt.header, got, tt.want)
+            }
+        })
+    }
+}